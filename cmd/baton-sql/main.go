@@ -2,17 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"strings"
 
 	configSdk "github.com/conductorone/baton-sdk/pkg/config"
-	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
-	"github.com/conductorone/baton-sdk/pkg/field"
 	"github.com/conductorone/baton-sdk/pkg/types"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 
+	"github.com/conductorone/baton-sql/pkg/bsql"
 	"github.com/conductorone/baton-sql/pkg/config"
 	"github.com/conductorone/baton-sql/pkg/connector"
 )
@@ -26,9 +27,7 @@ func main() {
 		ctx,
 		"baton-sql",
 		getConnector,
-		field.Configuration{
-			Fields: config.ConfigurationFields,
-		},
+		config.ConfigurationSchema,
 	)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
@@ -47,15 +46,37 @@ func main() {
 func getConnector(ctx context.Context, v *viper.Viper) (types.ConnectorServer, error) {
 	l := ctxzap.Extract(ctx)
 
-	cb, err := connector.New(ctx, v.GetString("config-path"))
+	var opts []bsql.Option
+	if identityFile := v.GetString(config.AgeIdentityFileField.FieldName); identityFile != "" {
+		opts = append(opts, bsql.WithAgeIdentityFile(identityFile))
+	}
+
+	cb, err := newConnector(ctx, v, opts)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
 	}
-	connector, err := connectorbuilder.NewConnector(ctx, cb)
+	srv, err := connector.NewServer(ctx, cb)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
 	}
-	return connector, nil
+	return srv, nil
+}
+
+// newConnector creates the connector from whichever config source was set: inline YAML, base64 encoded YAML or a file path.
+func newConnector(ctx context.Context, v *viper.Viper, opts []bsql.Option) (*connector.Connector, error) {
+	if data := v.GetString(config.ConfigYAMLField.FieldName); data != "" {
+		return connector.NewFromYAML(ctx, []byte(data), opts...)
+	}
+
+	if encoded := v.GetString(config.ConfigYAMLBase64Field.FieldName); encoded != "" {
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", config.ConfigYAMLBase64Field.FieldName, err)
+		}
+		return connector.NewFromYAML(ctx, data, opts...)
+	}
+
+	return connector.New(ctx, v.GetString(config.ConfigPathField.FieldName), opts...)
 }
@@ -2,35 +2,63 @@ package bcel
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"maps"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/google/cel-go/cel"
-	"github.com/google/cel-go/common/types"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	sdkResource "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-sql/pkg/bcel/functions"
 	"github.com/conductorone/baton-sql/pkg/helpers"
 )
 
 type Env struct {
 	celEnv *cel.Env
+
+	// programs caches compiled programs by expression, since the same expressions are evaluated for every row.
+	programs sync.Map
+}
+
+// Option configures an Env.
+type Option func(*envOptions)
+
+type envOptions struct {
+	lookups   LookupTables
+	functions []FunctionDecl
 }
 
-func NewEnv(ctx context.Context) (*Env, error) {
+func NewEnv(ctx context.Context, opts ...Option) (*Env, error) {
+	o := &envOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	var celOpts []cel.EnvOption
 
 	// CEL variables
 	celOpts = append(celOpts,
+		inputTypes(),
 		cel.Variable("cols", cel.MapType(cel.StringType, cel.AnyType)),
-		cel.Variable("resource", cel.MapType(types.StringType, types.StringType)),
-		cel.Variable("principal", cel.MapType(types.StringType, types.StringType)),
-		cel.Variable("entitlement", cel.MapType(types.StringType, types.StringType)),
+		cel.Variable("resource", cel.ObjectType(resourceTypeName)),
+		cel.Variable("principal", cel.ObjectType(resourceTypeName)),
+		cel.Variable("entitlement", cel.ObjectType(entitlementTypeName)),
 	)
 
 	// CEL functions
-	celOpts = append(celOpts, functions.GetAllOptions()...)
+	fnOpts := append(functions.GetAllOptions(), lookupFunction(o.lookups))
+	userFnOpts, err := compileFunctions(fnOpts, o.functions)
+	if err != nil {
+		return nil, err
+	}
+	celOpts = append(celOpts, fnOpts...)
+	celOpts = append(celOpts, userFnOpts...)
 
 	celEnv, err := cel.NewEnv(celOpts...)
 	if err != nil {
@@ -41,15 +69,33 @@ func NewEnv(ctx context.Context) (*Env, error) {
 	}, nil
 }
 
-func (t *Env) Evaluate(ctx context.Context, expr string, inputs map[string]any) (any, error) {
-	expr = preprocessExpressions(expr)
+// Compile type checks expr and caches its program for Evaluate.
+func (t *Env) Compile(expr string) error {
+	_, err := t.program(expr)
+	return err
+}
+
+func (t *Env) program(expr string) (cel.Program, error) {
+	if prg, ok := t.programs.Load(expr); ok {
+		return prg.(cel.Program), nil
+	}
 
-	ast, issues := t.celEnv.Compile(expr)
+	ast, issues := t.celEnv.Compile(preprocessExpressions(expr))
 	if issues != nil && issues.Err() != nil {
-		return "", issues.Err()
+		return nil, issues.Err()
 	}
 
 	prg, err := t.celEnv.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	t.programs.Store(expr, prg)
+
+	return prg, nil
+}
+
+func (t *Env) Evaluate(ctx context.Context, expr string, inputs map[string]any) (any, error) {
+	prg, err := t.program(expr)
 	if err != nil {
 		return "", err
 	}
@@ -78,6 +124,15 @@ func (t *Env) EvaluateString(ctx context.Context, expr string, inputs map[string
 		return ret, nil
 	case int64, int32, int, uint64, uint32, uint:
 		return fmt.Sprintf("%d", ret), nil
+	case float64:
+		return strconv.FormatFloat(ret, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(ret), nil
+	case time.Time:
+		return ret.Format(time.RFC3339Nano), nil
+	case nil, structpb.NullValue:
+		// A null, e.g. principal.Login of a principal without a login, is an empty string.
+		return "", nil
 	default:
 		return fmt.Sprintf("%s", ret), nil
 	}
@@ -109,21 +164,60 @@ func (t *Env) SyncInputs(rowMap map[string]any) map[string]any {
 	ret := make(map[string]any)
 
 	if rowMap != nil {
-		ret["cols"] = rowMap
+		ret["cols"] = normalizeRow(rowMap)
 	}
 
 	return ret
 }
 
+// normalizeRow converts the time values that drivers return into UTC times, which CEL sees as timestamps.
+// The row is only copied if it has a value to convert.
+func normalizeRow(rowMap map[string]any) map[string]any {
+	var ret map[string]any
+	for k, v := range rowMap {
+		nv, ok := normalizeTime(v)
+		if !ok {
+			continue
+		}
+		if ret == nil {
+			ret = maps.Clone(rowMap)
+		}
+		ret[k] = nv
+	}
+
+	if ret == nil {
+		return rowMap
+	}
+	return ret
+}
+
+func normalizeTime(v any) (any, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		if t.Location() == time.UTC {
+			return nil, false
+		}
+		return t.UTC(), true
+	case *time.Time:
+		if t == nil {
+			return nil, true
+		}
+		return t.UTC(), true
+	case sql.NullTime:
+		if !t.Valid {
+			return nil, true
+		}
+		return t.Time.UTC(), true
+	default:
+		return nil, false
+	}
+}
+
 func (t *Env) SyncInputsWithResource(rowMap map[string]any, resource *v2.Resource) map[string]any {
 	ret := t.SyncInputs(rowMap)
 
 	if resource != nil {
-		ret["resource"] = map[string]string{
-			"ID":          resource.Id.Resource,
-			"Type":        resource.Id.ResourceType,
-			"DisplayName": resource.DisplayName,
-		}
+		ret["resource"] = resourceInput(resource)
 	}
 
 	return ret
@@ -140,24 +234,73 @@ func (t *Env) ProvisioningInputs(principal *v2.Resource, entitlement *v2.Entitle
 
 	ret := make(map[string]any)
 
-	ret["principal"] = map[string]string{
-		"ID":   principal.Id.Resource,
-		"Type": principal.Id.ResourceType,
-	}
+	ret["principal"] = resourceInput(principal)
 
 	resourceType, resourceID, entitlementID, err := helpers.SplitEntitlementID(entitlement)
 	if err != nil {
 		return nil, err
 	}
 
-	ret["entitlement"] = map[string]string{
-		"ID": entitlementID,
+	ret["entitlement"] = map[string]any{
+		"ID":          entitlementID,
+		"DisplayName": entitlement.GetDisplayName(),
+		"Slug":        entitlement.GetSlug(),
+		"Description": entitlement.GetDescription(),
 	}
 
-	ret["resource"] = map[string]string{
-		"ID":   resourceID,
-		"Type": resourceType,
+	resource := entitlement.GetResource()
+	if resource == nil {
+		resource = &v2.Resource{}
 	}
+	ri := resourceInput(resource)
+	ri["ID"] = resourceID
+	ri["Type"] = resourceType
+	ret["resource"] = ri
 
 	return ret, nil
 }
+
+// resourceInput describes a resource to CEL as a baton.Resource. Login, Emails and Profile come from the resource's
+// traits. Login is null when the resource has no user trait, so has(principal.Login) tells whether there is one;
+// the other fields are empty rather than missing. Emails lists the primary address first. Numbers in Profile are
+// doubles.
+func resourceInput(r *v2.Resource) map[string]any {
+	ret := map[string]any{
+		"ID":          r.GetId().GetResource(),
+		"Type":        r.GetId().GetResourceType(),
+		"DisplayName": r.GetDisplayName(),
+		"Description": r.GetDescription(),
+		"ParentID":    r.GetParentResourceId().GetResource(),
+		"ParentType":  r.GetParentResourceId().GetResourceType(),
+		"Login":       nil,
+		"Emails":      []string{},
+		"Profile":     map[string]any{},
+	}
+
+	var profile *structpb.Struct
+	if ut, err := sdkResource.GetUserTrait(r); err == nil {
+		ret["Login"] = ut.GetLogin()
+
+		emails := make([]string, 0, len(ut.GetEmails()))
+		for _, e := range ut.GetEmails() {
+			if e.GetIsPrimary() {
+				emails = append([]string{e.GetAddress()}, emails...)
+			} else {
+				emails = append(emails, e.GetAddress())
+			}
+		}
+		ret["Emails"] = emails
+		profile = ut.GetProfile()
+	} else if gt, err := sdkResource.GetGroupTrait(r); err == nil {
+		profile = gt.GetProfile()
+	} else if rt, err := sdkResource.GetRoleTrait(r); err == nil {
+		profile = rt.GetProfile()
+	} else if at, err := sdkResource.GetAppTrait(r); err == nil {
+		profile = at.GetProfile()
+	}
+	if profile != nil {
+		ret["Profile"] = profile.AsMap()
+	}
+
+	return ret
+}
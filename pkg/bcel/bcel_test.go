@@ -2,9 +2,13 @@ package bcel
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	sdkResource "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/stretchr/testify/require"
 
 	"github.com/conductorone/baton-sql/pkg/bcel/functions"
@@ -30,3 +34,218 @@ func TestTemplateEnv_Evaluate(tt *testing.T) {
 		}
 	}
 }
+
+func TestEnv_SyncInputs_times(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	created := time.Date(2024, 1, 2, 4, 4, 5, 0, time.FixedZone("CET", 3600))
+	rowMap := map[string]any{
+		"created_at": created,
+		"deleted_at": sql.NullTime{},
+		"name":       "alice",
+	}
+	inputs := env.SyncInputs(rowMap)
+
+	out, err := env.EvaluateString(ctx, ".created_at", inputs)
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-02T03:04:05Z", out)
+
+	isNull, err := env.EvaluateBool(ctx, ".deleted_at == null", inputs)
+	require.NoError(t, err)
+	require.True(t, isNull)
+
+	require.Equal(t, created, rowMap["created_at"], "the row must not be modified")
+}
+
+func TestEnv_lookup_notConfigured(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	_, err = env.Evaluate(ctx, "lookup('departments', 1)", map[string]any{})
+	require.ErrorContains(t, err, "no lookups are configured")
+}
+
+func TestEnv_ProvisioningInputs(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	userType := &v2.ResourceType{Id: "user"}
+	principal, err := sdkResource.NewUserResource("Alice", userType, "42", []sdkResource.UserTraitOption{
+		sdkResource.WithUserLogin("alice"),
+		sdkResource.WithEmail("alice@old.example.com", false),
+		sdkResource.WithEmail("alice@example.com", true),
+		sdkResource.WithUserProfile(map[string]any{"db_user": "app_alice", "level": 3}),
+	}, sdkResource.WithDescription("Staff engineer"))
+	require.NoError(t, err)
+
+	roleType := &v2.ResourceType{Id: "role"}
+	role, err := sdkResource.NewGroupResource("Editors", roleType, "editor", []sdkResource.GroupTraitOption{
+		sdkResource.WithGroupProfile(map[string]any{"grantable": true}),
+	}, sdkResource.WithParentResourceID(&v2.ResourceId{ResourceType: "site", Resource: "blog"}))
+	require.NoError(t, err)
+
+	inputs, err := env.ProvisioningInputs(principal, &v2.Entitlement{
+		Id:          "role:editor:member",
+		DisplayName: "Editors Member",
+		Slug:        "member",
+		Resource:    role,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{"principal.ID", "42"},
+		{"principal.Login", "alice"},
+		{"principal.Emails[0]", "alice@example.com"},
+		{"size(principal.Emails)", int64(2)},
+		{"principal.Profile.db_user", "app_alice"},
+		{"principal.Profile.level == 3.0", true},
+		{"principal.Description", "Staff engineer"},
+		{"principal.ParentID", ""},
+		{"entitlement.ID", "member"},
+		{"entitlement.DisplayName", "Editors Member"},
+		{"entitlement.Slug", "member"},
+		{"resource.ID", "editor"},
+		{"resource.Type", "role"},
+		{"resource.DisplayName", "Editors"},
+		{"resource.ParentID + '/' + resource.ParentType", "blog/site"},
+		{"resource.Profile.grantable", true},
+		{"!has(resource.Login) && resource.Login == null && size(resource.Emails) == 0", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			out, err := env.Evaluate(ctx, tt.expr, inputs)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, out)
+		})
+	}
+}
+
+func TestEnv_ProvisioningInputs_withoutResource(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	inputs, err := env.ProvisioningInputs(&v2.Resource{Id: &v2.ResourceId{ResourceType: "user", Resource: "42"}}, &v2.Entitlement{
+		Id: "role:editor:member",
+	})
+	require.NoError(t, err)
+
+	out, err := env.EvaluateString(ctx, "resource.Type + ':' + resource.ID + ':' + entitlement.ID", inputs)
+	require.NoError(t, err)
+	require.Equal(t, "role:editor:member", out)
+
+	_, err = env.Evaluate(ctx, "'db_' + principal.Login", inputs)
+	require.Error(t, err)
+}
+
+func TestEnv_Compile_inputFields(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, env.Compile("has(principal.Login) ? principal.Login : principal.ID"))
+	require.NoError(t, env.Compile("entitlement.Slug + resource.Profile.team"))
+
+	err = env.Compile("principal.Logn")
+	require.ErrorContains(t, err, "undefined field 'Logn'")
+	err = env.Compile("entitlement.Login")
+	require.ErrorContains(t, err, "undefined field 'Login'")
+	err = env.Compile("size(resource.ID) + resource.DisplayName")
+	require.Error(t, err)
+}
+
+func TestEnv_SyncInputsWithResource(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	userType := &v2.ResourceType{Id: "user"}
+	user, err := sdkResource.NewUserResource("Bob", userType, "7", []sdkResource.UserTraitOption{
+		sdkResource.WithUserLogin("bob"),
+	})
+	require.NoError(t, err)
+
+	inputs := env.SyncInputsWithResource(map[string]any{"login": "bob"}, user)
+	out, err := env.EvaluateBool(ctx, ".login == resource.Login && resource.DisplayName == 'Bob'", inputs)
+	require.NoError(t, err)
+	require.True(t, out)
+}
+
+func TestEnv_EvaluateString_nullLogin(t *testing.T) {
+	ctx := context.Background()
+
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	// A principal without the user trait has no login.
+	inputs, err := env.ProvisioningInputs(&v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: "role", Resource: "editor"},
+		DisplayName: "Editors",
+	}, &v2.Entitlement{
+		Id:       "role:admin:member",
+		Slug:     "member",
+		Resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: "role", Resource: "admin"}},
+	})
+	require.NoError(t, err)
+
+	out, err := env.EvaluateString(ctx, "principal.Login", inputs)
+	require.NoError(t, err)
+	require.Equal(t, "", out)
+}
+
+func TestEnv_Compile(t *testing.T) {
+	ctx := context.Background()
+
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	require.Error(t, env.Compile("noSuchFunction(.id)"))
+	require.NoError(t, env.Compile("string(.id)"))
+
+	_, ok := env.programs.Load("string(.id)")
+	require.True(t, ok)
+
+	out, err := env.EvaluateString(ctx, "string(.id)", env.SyncInputs(map[string]any{"id": int64(4)}))
+	require.NoError(t, err)
+	require.Equal(t, "4", out)
+}
+
+func TestEnv_EvaluateString(t *testing.T) {
+	ctx := context.Background()
+
+	env, err := NewEnv(ctx)
+	require.NoError(t, err)
+
+	inputs := env.SyncInputs(map[string]any{
+		"balance": 12.5,
+		"id":      1234567.0,
+		"active":  true,
+		"count":   int64(3),
+		"email":   nil,
+	})
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{".balance", "12.5"},
+		{".id", "1234567"},
+		{".active", "true"},
+		{".count", "3"},
+		{".count > 5", "false"},
+		{".email", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			out, err := env.EvaluateString(ctx, tt.expr, inputs)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, out)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package functions
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// HasBit reports whether all the bits of flag are set in mask, as in mask & flag == flag. A zero flag is never set.
+func HasBit(mask int64, flag int64) bool {
+	return flag != 0 && mask&flag == flag
+}
+
+// BitsSet returns the value of each bit set in mask, in ascending order. A mask of 13 returns [1, 4, 8].
+func BitsSet(mask int64) []int64 {
+	ret := make([]int64, 0, bits.OnesCount64(uint64(mask)))
+	for m := uint64(mask); m != 0; m &= m - 1 {
+		ret = append(ret, int64(m&-m))
+	}
+	return ret
+}
+
+// FlagNames returns the names of the flags set in mask, ordered by flag value. Flags can span several bits,
+// and set bits without a name are ignored.
+func FlagNames(mask int64, names map[int64]string) []string {
+	flags := make([]int64, 0, len(names))
+	for flag := range names {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return uint64(flags[i]) < uint64(flags[j]) })
+
+	ret := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		if HasBit(mask, flag) {
+			ret = append(ret, names[flag])
+		}
+	}
+	return ret
+}
+
+func HasBitFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "hasBit",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "hasBit_int_int",
+				Args:       []*types.Type{types.IntType, types.IntType},
+				ResultType: types.BoolType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					mask, ok1 := lhs.Value().(int64)
+					flag, ok2 := rhs.Value().(int64)
+					if !ok1 || !ok2 {
+						return types.NewErr("invalid arguments to hasBit, expected ints")
+					}
+					return types.Bool(HasBit(mask, flag))
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `hasBit(13, 4)`,
+						Expected: true,
+					},
+					{
+						Expr:     `hasBit(13, 2)`,
+						Expected: false,
+					},
+					{
+						Expr:     `hasBit(13, 12)`,
+						Expected: true,
+					},
+					{
+						Expr:     `hasBit(cols["perm_flags"], 4)`,
+						Expected: true,
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"perm_flags": int64(5),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func BitsSetFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "bitsSet",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "bitsSet_int",
+				Args:       []*types.Type{types.IntType},
+				ResultType: types.NewListType(types.IntType),
+				Unary: func(v ref.Val) ref.Val {
+					mask, ok := v.Value().(int64)
+					if !ok {
+						return types.NewErr("invalid argument to bitsSet, expected int")
+					}
+					return types.DefaultTypeAdapter.NativeToValue(BitsSet(mask))
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `bitsSet(13)`,
+						Expected: []int64{1, 4, 8},
+					},
+					{
+						Expr:     `size(bitsSet(0))`,
+						Expected: int64(0),
+					},
+					{
+						Expr:     `4 in bitsSet(5)`,
+						Expected: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func FlagNamesFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "flagNames",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "flagNames_int_map",
+				Args:       []*types.Type{types.IntType, types.NewMapType(types.IntType, types.StringType)},
+				ResultType: types.NewListType(types.StringType),
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					mask, ok1 := lhs.Value().(int64)
+					m, ok2 := rhs.(traits.Mapper)
+					if !ok1 || !ok2 {
+						return types.NewErr("invalid arguments to flagNames, expected an int and a map of ints to strings")
+					}
+
+					names := make(map[int64]string)
+					it := m.Iterator()
+					for it.HasNext() == types.True {
+						k := it.Next()
+						flag, ok := k.Value().(int64)
+						if !ok {
+							return types.NewErr("invalid flag %v in flagNames, expected int", k)
+						}
+						name, ok := m.Get(k).Value().(string)
+						if !ok {
+							return types.NewErr("invalid name for flag %d in flagNames, expected string", flag)
+						}
+						names[flag] = name
+					}
+
+					return types.NewStringList(types.DefaultTypeAdapter, FlagNames(mask, names))
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `flagNames(13, {1: 'read', 2: 'write', 4: 'approve', 8: 'admin'})`,
+						Expected: []string{"read", "approve", "admin"},
+					},
+					{
+						Expr:     `flagNames(0, {1: 'read'})`,
+						Expected: []string{},
+					},
+					{
+						Expr:     `'approve' in flagNames(cols["perm_flags"], {4: 'approve'})`,
+						Expected: true,
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"perm_flags": int64(6),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
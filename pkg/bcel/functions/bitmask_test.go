@@ -0,0 +1,50 @@
+package functions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBitsSet(t *testing.T) {
+	tests := []struct {
+		mask int64
+		want []int64
+	}{
+		{0, []int64{}},
+		{1, []int64{1}},
+		{13, []int64{1, 4, 8}},
+		{-1 << 62, []int64{1 << 62, -1 << 63}},
+	}
+	for _, tt := range tests {
+		if got := BitsSet(tt.mask); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BitsSet(%d) = %v, want %v", tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestFlagNames(t *testing.T) {
+	names := map[int64]string{8: "admin", 1: "read", 2: "write", 4: "approve", 6: "editor"}
+	tests := []struct {
+		mask int64
+		want []string
+	}{
+		{0, []string{}},
+		{5, []string{"read", "approve"}},
+		{6, []string{"write", "approve", "editor"}},
+		{16, []string{}},
+	}
+	for _, tt := range tests {
+		if got := FlagNames(tt.mask, names); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FlagNames(%d) = %v, want %v", tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestHasBit(t *testing.T) {
+	if HasBit(5, 0) {
+		t.Error("HasBit() must be false for a zero flag")
+	}
+	if !HasBit(5, 4) || HasBit(5, 2) {
+		t.Error("HasBit() returned the wrong result for single bits")
+	}
+}
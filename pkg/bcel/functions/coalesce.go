@@ -0,0 +1,157 @@
+package functions
+
+import (
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+func isNull(v ref.Val) bool {
+	return v.Type() == types.NullType
+}
+
+// isEmpty reports whether v is null, an empty string or empty bytes.
+func isEmpty(v ref.Val) bool {
+	switch t := v.Value().(type) {
+	case string:
+		return t == ""
+	case []byte:
+		return len(t) == 0
+	default:
+		return isNull(v)
+	}
+}
+
+func coalesceVals(args ...ref.Val) ref.Val {
+	for _, v := range args {
+		if !isNull(v) {
+			return v
+		}
+	}
+	return types.String("")
+}
+
+func CoalesceFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "coalesce",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "coalesce_list",
+				Args:       []*types.Type{types.NewListType(types.DynType)},
+				ResultType: types.DynType,
+				Unary: func(v ref.Val) ref.Val {
+					list, ok := v.(traits.Lister)
+					if !ok {
+						return types.NewErr("invalid argument to coalesce, expected list")
+					}
+					var args []ref.Val
+					it := list.Iterator()
+					for it.HasNext() == types.True {
+						args = append(args, it.Next())
+					}
+					return coalesceVals(args...)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `coalesce([null, 'nickname', 'name'])`,
+						Expected: "nickname",
+					},
+					{
+						Expr:     `coalesce([null, null])`,
+						Expected: "",
+					},
+				},
+			},
+			{
+				Operator:   "coalesce_dyn_dyn",
+				Args:       []*types.Type{types.DynType, types.DynType},
+				ResultType: types.DynType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					return coalesceVals(lhs, rhs)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `coalesce(cols["nickname"], cols["name"])`,
+						Expected: "alice",
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"nickname": nil,
+								"name":     "alice",
+							},
+						},
+					},
+					{
+						Expr:     `coalesce('', 'name')`,
+						Expected: "",
+					},
+				},
+			},
+			{
+				Operator:   "coalesce_dyn_dyn_dyn",
+				Args:       []*types.Type{types.DynType, types.DynType, types.DynType},
+				ResultType: types.DynType,
+				Function:   coalesceVals,
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `coalesce(null, null, 3)`,
+						Expected: int64(3),
+					},
+				},
+			},
+			{
+				Operator:   "coalesce_dyn_dyn_dyn_dyn",
+				Args:       []*types.Type{types.DynType, types.DynType, types.DynType, types.DynType},
+				ResultType: types.DynType,
+				Function:   coalesceVals,
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `coalesce(null, null, null, null)`,
+						Expected: "",
+					},
+				},
+			},
+		},
+	}
+}
+
+func DefaultFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "default",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "default_dyn_dyn",
+				Args:       []*types.Type{types.DynType, types.DynType},
+				ResultType: types.DynType,
+				Binary: func(value ref.Val, fallback ref.Val) ref.Val {
+					if isEmpty(value) {
+						return fallback
+					}
+					return value
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `default(cols["department"], 'Unassigned')`,
+						Expected: "Unassigned",
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"department": nil,
+							},
+						},
+					},
+					{
+						Expr:     `default('', 'Unassigned')`,
+						Expected: "Unassigned",
+					},
+					{
+						Expr:     `default('Sales', 'Unassigned')`,
+						Expected: "Sales",
+					},
+					{
+						Expr:     `default(0, 1)`,
+						Expected: int64(0),
+					},
+				},
+			},
+		},
+	}
+}
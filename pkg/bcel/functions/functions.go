@@ -22,6 +22,7 @@ type OverloadDefinition struct {
 	ResultType *cel.Type
 	Unary      functions.UnaryOp
 	Binary     functions.BinaryOp
+	Function   functions.FunctionOp
 	TestCases  []*ExprTestCase
 }
 
@@ -55,6 +56,15 @@ func (fd *FunctionDefinition) GetOptions() []cel.EnvOption {
 					cel.BinaryBinding(overload.Binary),
 				),
 			)
+		case overload.Function != nil:
+			fn = cel.Function(fd.Name,
+				cel.Overload(
+					overload.Operator,
+					overload.Args,
+					overload.ResultType,
+					cel.FunctionBinding(overload.Function),
+				),
+			)
 		default:
 			panic("invalid overload definition")
 		}
@@ -69,9 +79,30 @@ func GetAllFunctions() []*FunctionDefinition {
 		ToUpperFunc(),
 		PHPDeserializeStringArrayFunc(),
 		PHPSerializeStringArrayFunc(),
+		PHPUnserializeFunc(),
+		PHPSerializeFunc(),
 		TitleCaseFunc(),
 		ToLowerFunc(),
 		SlugifyFunc(),
+		ParseJSONFunc(),
+		ToJSONFunc(),
+		JSONPathFunc(),
+		ParseTimeFunc(),
+		FormatTimeFunc(),
+		UnixTimeFunc(),
+		ToTimestampFunc(),
+		RegexReplaceFunc(),
+		RegexExtractFunc(),
+		SplitFunc(),
+		JoinFunc(),
+		TrimFunc(),
+		PadLeftFunc(),
+		CoalesceFunc(),
+		DefaultFunc(),
+		HashFunc(),
+		HasBitFunc(),
+		BitsSetFunc(),
+		FlagNamesFunc(),
 	}
 }
 
@@ -0,0 +1,112 @@
+package functions
+
+import (
+	"crypto/md5"  //nolint:gosec // md5 is offered to match ids that legacy apps already store as md5.
+	"crypto/sha1" //nolint:gosec // sha1 is offered to match ids that legacy apps already store as sha1.
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+const defaultHashAlgorithm = "sha256"
+
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Hash returns the hex encoded digest of data using algorithm, one of md5, sha1, sha256 or sha512.
+func Hash(data []byte, algorithm string) (string, error) {
+	newHash, ok := hashAlgorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("unsupported hash algorithm %q", algorithm)
+	}
+
+	h := newHash()
+	h.Write(data)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashVal(v ref.Val, algorithm string) ref.Val {
+	var data []byte
+	switch t := v.Value().(type) {
+	case string:
+		data = []byte(t)
+	case []byte:
+		data = t
+	default:
+		return types.NewErr("invalid argument to hash, expected string or bytes")
+	}
+
+	result, err := Hash(data, algorithm)
+	if err != nil {
+		return types.NewErr("error in hash: %s", err)
+	}
+	return types.String(result)
+}
+
+func HashFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "hash",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "hash_string",
+				Args:       []*types.Type{types.StringType},
+				ResultType: types.StringType,
+				Unary: func(v ref.Val) ref.Val {
+					return hashVal(v, defaultHashAlgorithm)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `hash('alice')`,
+						Expected: "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90",
+					},
+				},
+			},
+			{
+				Operator:   "hash_bytes",
+				Args:       []*types.Type{types.BytesType},
+				ResultType: types.StringType,
+				Unary: func(v ref.Val) ref.Val {
+					return hashVal(v, defaultHashAlgorithm)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `hash(b'alice')`,
+						Expected: "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90",
+					},
+				},
+			},
+			{
+				Operator:   "hash_string_string",
+				Args:       []*types.Type{types.StringType, types.StringType},
+				ResultType: types.StringType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					algorithm, ok := rhs.Value().(string)
+					if !ok {
+						return types.NewErr("invalid algorithm argument to hash, expected string")
+					}
+					return hashVal(lhs, algorithm)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `hash('alice', 'md5')`,
+						Expected: "6384e2b2184bcbf58eccf10ca7a6563c",
+					},
+					{
+						Expr:     `hash('alice', 'sha256') == hash('alice')`,
+						Expected: true,
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package functions
+
+import "testing"
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		want      string
+		wantErr   bool
+	}{
+		{"sha256", "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90", false},
+		{"sha1", "522b276a356bdf39013dfabea2cd43e141ecc9e8", false},
+		{"md5", "6384e2b2184bcbf58eccf10ca7a6563c", false},
+		{"crc32", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			got, err := Hash([]byte("alice"), tt.algorithm)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Hash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Hash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var jsonValueType = reflect.TypeOf(&structpb.Value{})
+
+// ParseJSON decodes a JSON document. Objects decode to maps, arrays to lists, and numbers to int64 when they are
+// integers and float64 otherwise.
+func ParseJSON(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var ret any
+	err := dec.Decode(&ret)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+
+	return normalizeJSON(ret), nil
+}
+
+func normalizeJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			t[k] = normalizeJSON(e)
+		}
+		return t
+	case []any:
+		for i, e := range t {
+			t[i] = normalizeJSON(e)
+		}
+		return t
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+// ToJSON encodes a value as JSON. Map keys are sorted.
+func ToJSON(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	err := enc.Encode(v)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonNative converts a CEL value to the Go value it encodes as in JSON. Integers stay int64 or uint64, rather than
+// going through float64 as structpb values do, so large IDs keep their precision.
+func jsonNative(v ref.Val) (any, error) {
+	switch t := v.(type) {
+	case types.Null:
+		return nil, nil
+	case types.Bool, types.Int, types.Uint, types.Double, types.String, types.Bytes:
+		return t.Value(), nil
+	case types.Timestamp:
+		return t.Time, nil
+	case types.Duration:
+		return t.Duration.String(), nil
+	case traits.Mapper:
+		ret := make(map[string]any)
+		for it := t.Iterator(); it.HasNext() == types.True; {
+			k := it.Next()
+			key, err := jsonKey(k)
+			if err != nil {
+				return nil, err
+			}
+			e, err := jsonNative(t.Get(k))
+			if err != nil {
+				return nil, err
+			}
+			ret[key] = e
+		}
+		return ret, nil
+	case traits.Lister:
+		var ret []any
+		for it := t.Iterator(); it.HasNext() == types.True; {
+			e, err := jsonNative(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, e)
+		}
+		if ret == nil {
+			ret = []any{}
+		}
+		return ret, nil
+	default:
+		native, err := v.ConvertToNative(jsonValueType)
+		if err != nil {
+			return nil, err
+		}
+		return native.(*structpb.Value).AsInterface(), nil
+	}
+}
+
+// jsonKey formats a map key as a JSON object key.
+func jsonKey(k ref.Val) (string, error) {
+	switch t := k.(type) {
+	case types.String:
+		return string(t), nil
+	case types.Int:
+		return strconv.FormatInt(int64(t), 10), nil
+	case types.Uint:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case types.Bool:
+		return strconv.FormatBool(bool(t)), nil
+	default:
+		return "", fmt.Errorf("unsupported map key type %s", k.Type())
+	}
+}
+
+// JSONPath returns the value at path in a JSON document, or nil if there is none. Paths are written
+// as $.roles[0].name, where the leading $ is optional and keys can also be written as ['key'].
+func JSONPath(s string, path string) (any, error) {
+	doc, err := ParseJSON(s)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := doc
+	for _, seg := range segments {
+		switch t := cur.(type) {
+		case map[string]any:
+			cur = t[seg]
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, nil
+			}
+			cur = t[i]
+		default:
+			return nil, nil
+		}
+	}
+
+	return cur, nil
+}
+
+func parseJSONPath(path string) ([]string, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+
+	var ret []string
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: empty key", path)
+			}
+			ret = append(ret, p[:end])
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: missing ]", path)
+			}
+			seg := p[1:end]
+			if len(seg) >= 2 && (seg[0] == '\'' || seg[0] == '"') && seg[len(seg)-1] == seg[0] {
+				seg = seg[1 : len(seg)-1]
+			} else if _, err := strconv.Atoi(seg); err != nil {
+				return nil, fmt.Errorf("invalid JSON path %q: index %q is not a number", path, seg)
+			}
+			ret = append(ret, seg)
+			p = p[end+1:]
+		default:
+			if len(ret) > 0 {
+				return nil, fmt.Errorf("invalid JSON path %q", path)
+			}
+			p = "." + p
+		}
+	}
+
+	return ret, nil
+}
+
+// jsonText returns the text of a string or bytes argument.
+func jsonText(v ref.Val) (string, bool) {
+	switch t := v.Value().(type) {
+	case string:
+		return t, true
+	case []byte:
+		return string(t), true
+	default:
+		return "", false
+	}
+}
+
+func parseJSONVal(v ref.Val) ref.Val {
+	input, ok := jsonText(v)
+	if !ok {
+		return types.NewErr("invalid argument to parseJSON, expected string or bytes")
+	}
+	result, err := ParseJSON(input)
+	if err != nil {
+		return types.NewErr("error while parsing JSON: %s", err)
+	}
+	return types.DefaultTypeAdapter.NativeToValue(result)
+}
+
+func ParseJSONFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "parseJSON",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "parseJSON_string",
+				Args:       []*types.Type{types.StringType},
+				ResultType: types.DynType,
+				Unary:      parseJSONVal,
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `parseJSON('{"roles": ["admin", "editor"]}').roles[1]`,
+						Expected: "editor",
+					},
+					{
+						Expr:     `parseJSON('{"id": 42}').id == 42`,
+						Expected: true,
+					},
+					{
+						Expr:     `"admin" in parseJSON('["admin", "editor"]')`,
+						Expected: true,
+					},
+					{
+						Expr:     `parseJSON('{"active": true}').active`,
+						Expected: true,
+					},
+					{
+						Expr:     `parseJSON(cols["attrs"]).department`,
+						Expected: "Engineering",
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"attrs": `{"department": "Engineering"}`,
+							},
+						},
+					},
+				},
+			},
+			{
+				Operator:   "parseJSON_bytes",
+				Args:       []*types.Type{types.BytesType},
+				ResultType: types.DynType,
+				Unary:      parseJSONVal,
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `parseJSON(b'{"level": 3}').level`,
+						Expected: int64(3),
+					},
+				},
+			},
+		},
+	}
+}
+
+func ToJSONFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "toJSON",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "toJSON_dyn",
+				Args:       []*types.Type{types.DynType},
+				ResultType: types.StringType,
+				Unary: func(v ref.Val) ref.Val {
+					native, err := jsonNative(v)
+					if err != nil {
+						return types.NewErr("invalid argument to toJSON: %s", err)
+					}
+					result, err := ToJSON(native)
+					if err != nil {
+						return types.NewErr("error while encoding JSON: %s", err)
+					}
+					return types.String(result)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `toJSON(['admin', 'editor'])`,
+						Expected: `["admin","editor"]`,
+					},
+					{
+						Expr:     `toJSON({'b': 1, 'a': true})`,
+						Expected: `{"a":true,"b":1}`,
+					},
+					{
+						Expr:     `toJSON('<tag>')`,
+						Expected: `"<tag>"`,
+					},
+					{
+						Expr:     `toJSON(parseJSON('{"roles": ["admin"]}').roles)`,
+						Expected: `["admin"]`,
+					},
+					{
+						Expr:     `toJSON({'id': 9007199254740993, 'ratio': 0.5})`,
+						Expected: `{"id":9007199254740993,"ratio":0.5}`,
+					},
+					{
+						Expr:     `toJSON({1: timestamp('2024-03-01T09:30:00Z')})`,
+						Expected: `{"1":"2024-03-01T09:30:00Z"}`,
+					},
+				},
+			},
+		},
+	}
+}
+
+func jsonPathVal(doc ref.Val, path ref.Val) ref.Val {
+	input, ok := jsonText(doc)
+	if !ok {
+		return types.NewErr("invalid argument to jsonPath, expected string or bytes")
+	}
+	p, ok := path.Value().(string)
+	if !ok {
+		return types.NewErr("invalid path argument to jsonPath, expected string")
+	}
+	result, err := JSONPath(input, p)
+	if err != nil {
+		return types.NewErr("error while evaluating JSON path: %s", err)
+	}
+	return types.DefaultTypeAdapter.NativeToValue(result)
+}
+
+func JSONPathFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "jsonPath",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "jsonPath_string_string",
+				Args:       []*types.Type{types.StringType, types.StringType},
+				ResultType: types.DynType,
+				Binary:     jsonPathVal,
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `jsonPath('{"profile": {"roles": [{"name": "admin"}]}}', '$.profile.roles[0].name')`,
+						Expected: "admin",
+					},
+					{
+						Expr:     `jsonPath('{"a b": {"c": 1}}', "$['a b'].c")`,
+						Expected: int64(1),
+					},
+					{
+						Expr:     `jsonPath('{"profile": {}}', 'profile.department') == null`,
+						Expected: true,
+					},
+					{
+						Expr:     `jsonPath('[1, 2]', '[5]') == null`,
+						Expected: true,
+					},
+				},
+			},
+			{
+				Operator:   "jsonPath_bytes_string",
+				Args:       []*types.Type{types.BytesType, types.StringType},
+				ResultType: types.DynType,
+				Binary:     jsonPathVal,
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `jsonPath(b'{"status": "active"}', '$.status')`,
+						Expected: "active",
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,69 @@
+package functions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{"object", `{"a": 1, "b": 1.5, "c": null}`, map[string]any{"a": int64(1), "b": 1.5, "c": nil}, false},
+		{"array", `["x", true]`, []any{"x", true}, false},
+		{"string", `"x"`, "x", false},
+		{"invalid", `{`, nil, true},
+		{"trailing data", `{} {}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSON(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseJSON() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	doc := `{"user": {"roles": [{"name": "admin"}, {"name": "editor"}], "a.b": "dotted"}}`
+	tests := []struct {
+		name    string
+		path    string
+		want    any
+		wantErr bool
+	}{
+		{"nested", "$.user.roles[1].name", "editor", false},
+		{"without root", "user.roles[0].name", "admin", false},
+		{"quoted key", `$.user["a.b"]`, "dotted", false},
+		{"root", "$", map[string]any{"user": map[string]any{
+			"roles": []any{map[string]any{"name": "admin"}, map[string]any{"name": "editor"}},
+			"a.b":   "dotted",
+		}}, false},
+		{"missing key", "$.user.groups", nil, false},
+		{"index out of range", "$.user.roles[2]", nil, false},
+		{"key on list", "$.user.roles.name", nil, false},
+		{"empty key", "$.user..roles", nil, true},
+		{"unclosed bracket", "$.user[0", nil, true},
+		{"invalid index", "$.user.roles[x]", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPath(doc, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JSONPath() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JSONPath() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
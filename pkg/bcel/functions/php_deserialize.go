@@ -1,7 +1,11 @@
 package functions
 
 import (
+	"fmt"
+	"math"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/elliotchance/phpserialize"
 	"github.com/google/cel-go/common/types"
@@ -69,3 +73,302 @@ func PHPDeserializeStringArrayFunc() *FunctionDefinition {
 		},
 	}
 }
+
+// PHPUnserialize decodes a PHP serialized value. Non-empty arrays whose keys are 0..n-1 in order decode to lists,
+// other arrays, including empty ones, and objects decode to maps keyed by int64 or string, and scalars decode to bool,
+// int64, float64, string or nil. An empty array is a map so that `in` and key lookups work on it.
+// Object property names lose the visibility prefix PHP adds to protected and private properties.
+func PHPUnserialize(s string) (any, error) {
+	d := &phpDecoder{data: s}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("unexpected data after PHP value at offset %d", d.pos)
+	}
+	return v, nil
+}
+
+// maxPHPDepth bounds how deeply arrays and objects may nest, so that crafted input cannot exhaust the stack.
+const maxPHPDepth = 512
+
+type phpDecoder struct {
+	data  string
+	pos   int
+	depth int
+}
+
+func (d *phpDecoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid PHP serialized data at offset %d: %s", d.pos, fmt.Sprintf(format, args...))
+}
+
+// expect consumes s or fails.
+func (d *phpDecoder) expect(s string) error {
+	if !strings.HasPrefix(d.data[d.pos:], s) {
+		return d.errorf("expected %q", s)
+	}
+	d.pos += len(s)
+	return nil
+}
+
+// until returns the text up to the next end byte and consumes the end byte.
+func (d *phpDecoder) until(end byte) (string, error) {
+	i := strings.IndexByte(d.data[d.pos:], end)
+	if i < 0 {
+		return "", d.errorf("expected %q", end)
+	}
+	ret := d.data[d.pos : d.pos+i]
+	d.pos += i + 1
+	return ret, nil
+}
+
+func (d *phpDecoder) length() (int, error) {
+	raw, err := d.until(':')
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, d.errorf("invalid length %q", raw)
+	}
+	return n, nil
+}
+
+// str consumes a length prefixed, double quoted string. Lengths are in bytes.
+func (d *phpDecoder) str() (string, error) {
+	n, err := d.length()
+	if err != nil {
+		return "", err
+	}
+	if err := d.expect(`"`); err != nil {
+		return "", err
+	}
+	if d.pos+n > len(d.data) {
+		return "", d.errorf("string of length %d is truncated", n)
+	}
+	ret := d.data[d.pos : d.pos+n]
+	d.pos += n
+	if err := d.expect(`"`); err != nil {
+		return "", err
+	}
+	return ret, nil
+}
+
+func (d *phpDecoder) value() (any, error) {
+	if d.pos+2 > len(d.data) {
+		return nil, d.errorf("unexpected end of data")
+	}
+
+	kind := d.data[d.pos]
+	if kind == 'N' {
+		d.pos++
+		return nil, d.expect(";")
+	}
+	d.pos++
+	if err := d.expect(":"); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case 'b':
+		raw, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		switch raw {
+		case "0":
+			return false, nil
+		case "1":
+			return true, nil
+		default:
+			return nil, d.errorf("invalid bool %q", raw)
+		}
+	case 'i':
+		raw, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, d.errorf("invalid int %q", raw)
+		}
+		return i, nil
+	case 'd':
+		raw, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		switch raw {
+		case "INF":
+			return math.Inf(1), nil
+		case "-INF":
+			return math.Inf(-1), nil
+		case "NAN":
+			return math.NaN(), nil
+		}
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, d.errorf("invalid float %q", raw)
+		}
+		return f, nil
+	case 's':
+		s, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+		return s, d.expect(";")
+	case 'a':
+		return d.array(false)
+	case 'O':
+		if _, err := d.str(); err != nil {
+			return nil, err
+		}
+		if err := d.expect(":"); err != nil {
+			return nil, err
+		}
+		return d.array(true)
+	default:
+		d.pos -= 2
+		return nil, d.errorf("unsupported type %q", kind)
+	}
+}
+
+// minPHPElementSize is a lower bound on the bytes an array element takes, e.g. "i:0;N;".
+const minPHPElementSize = 4
+
+// array consumes the count and the {key;value...} body of an array or object.
+func (d *phpDecoder) array(object bool) (any, error) {
+	if d.depth >= maxPHPDepth {
+		return nil, d.errorf("arrays nested more than %d deep", maxPHPDepth)
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+
+	n, err := d.length()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.expect("{"); err != nil {
+		return nil, err
+	}
+	// The count comes from the input, so check it against the input before allocating for it.
+	if n > (len(d.data)-d.pos)/minPHPElementSize {
+		return nil, d.errorf("array of %d elements is truncated", n)
+	}
+
+	m := make(map[any]any, n)
+	list := make([]any, 0, n)
+	isList := !object && n > 0
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		switch key := k.(type) {
+		case int64:
+			if key != int64(i) {
+				isList = false
+			}
+		case string:
+			isList = false
+			if object {
+				k = phpPropertyName(key)
+			}
+		default:
+			return nil, d.errorf("invalid array key %v", k)
+		}
+
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+		list = append(list, v)
+	}
+
+	if err := d.expect("}"); err != nil {
+		return nil, err
+	}
+	if isList {
+		return list, nil
+	}
+	return m, nil
+}
+
+// phpPropertyName strips the "\0*\0" and "\0Class\0" prefixes of protected and private object properties.
+func phpPropertyName(s string) string {
+	if strings.HasPrefix(s, "\x00") {
+		if i := strings.IndexByte(s[1:], 0); i >= 0 {
+			return s[i+2:]
+		}
+	}
+	return s
+}
+
+func PHPUnserializeFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "phpUnserialize",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "phpUnserialize_string",
+				Args:       []*types.Type{types.StringType},
+				ResultType: types.DynType,
+				Unary: func(v ref.Val) ref.Val {
+					input, ok := v.Value().(string)
+					if !ok {
+						return types.NewErr("invalid argument to phpUnserialize, expected string")
+					}
+					result, err := PHPUnserialize(input)
+					if err != nil {
+						return types.NewErr("error while unserializing PHP string: %s", err)
+					}
+					return types.DefaultTypeAdapter.NativeToValue(result)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `phpUnserialize('a:2:{s:13:"administrator";b:1;s:6:"editor";b:0;}').administrator`,
+						Expected: true,
+					},
+					{
+						Expr:     `phpUnserialize('a:2:{s:13:"administrator";b:1;s:6:"editor";b:0;}')['editor']`,
+						Expected: false,
+					},
+					{
+						Expr:     `'administrator' in phpUnserialize('a:1:{s:13:"administrator";b:1;}')`,
+						Expected: true,
+					},
+					{
+						Expr:     `phpUnserialize('a:2:{i:0;s:5:"first";i:1;s:6:"second";}')[1]`,
+						Expected: "second",
+					},
+					{
+						Expr:     `phpUnserialize('a:1:{i:5;s:4:"five";}')[5]`,
+						Expected: "five",
+					},
+					{
+						Expr:     `phpUnserialize('a:1:{s:7:"options";a:1:{s:5:"level";i:3;}}').options.level`,
+						Expected: int64(3),
+					},
+					{
+						Expr:     `phpUnserialize('d:0.5;')`,
+						Expected: 0.5,
+					},
+					{
+						Expr:     `'administrator' in phpUnserialize('a:0:{}')`,
+						Expected: false,
+					},
+					{
+						Expr:     `phpUnserialize(cols["caps"]).filter(k, phpUnserialize(cols["caps"])[k]) == ['editor']`,
+						Expected: true,
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"caps": `a:2:{s:13:"administrator";b:0;s:6:"editor";b:1;}`,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
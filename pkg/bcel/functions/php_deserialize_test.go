@@ -2,6 +2,7 @@ package functions
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -57,3 +58,181 @@ func TestPHPDeserializeArray(t *testing.T) {
 		})
 	}
 }
+
+// wpUserRoles is the wp_user_roles option of a WordPress install with only the default roles, trimmed to the
+// capabilities WordPress 2.0 shipped with.
+const wpUserRoles = `a:5:{` +
+	`s:13:"administrator";a:2:{s:4:"name";s:13:"Administrator";s:12:"capabilities";a:35:{s:13:"switch_themes";b:1;` +
+	`s:11:"edit_themes";b:1;s:16:"activate_plugins";b:1;s:12:"edit_plugins";b:1;s:10:"edit_users";b:1;` +
+	`s:10:"edit_files";b:1;s:14:"manage_options";b:1;s:17:"moderate_comments";b:1;s:17:"manage_categories";b:1;` +
+	`s:12:"manage_links";b:1;s:12:"upload_files";b:1;s:6:"import";b:1;s:15:"unfiltered_html";b:1;` +
+	`s:10:"edit_posts";b:1;s:17:"edit_others_posts";b:1;s:20:"edit_published_posts";b:1;s:13:"publish_posts";b:1;` +
+	`s:10:"edit_pages";b:1;s:4:"read";b:1;s:8:"level_10";b:1;s:7:"level_9";b:1;s:7:"level_8";b:1;` +
+	`s:7:"level_7";b:1;s:7:"level_6";b:1;s:7:"level_5";b:1;s:7:"level_4";b:1;s:7:"level_3";b:1;s:7:"level_2";b:1;` +
+	`s:7:"level_1";b:1;s:7:"level_0";b:1;s:12:"delete_users";b:1;s:12:"create_users";b:1;s:10:"list_users";b:1;` +
+	`s:12:"remove_users";b:1;s:13:"promote_users";b:1;}}` +
+	`s:6:"editor";a:2:{s:4:"name";s:6:"Editor";s:12:"capabilities";a:19:{s:17:"moderate_comments";b:1;` +
+	`s:17:"manage_categories";b:1;s:12:"manage_links";b:1;s:12:"upload_files";b:1;s:15:"unfiltered_html";b:1;` +
+	`s:10:"edit_posts";b:1;s:17:"edit_others_posts";b:1;s:20:"edit_published_posts";b:1;s:13:"publish_posts";b:1;` +
+	`s:10:"edit_pages";b:1;s:4:"read";b:1;s:7:"level_7";b:1;s:7:"level_6";b:1;s:7:"level_5";b:1;s:7:"level_4";b:1;` +
+	`s:7:"level_3";b:1;s:7:"level_2";b:1;s:7:"level_1";b:1;s:7:"level_0";b:1;}}` +
+	`s:6:"author";a:2:{s:4:"name";s:6:"Author";s:12:"capabilities";a:10:{s:12:"upload_files";b:1;` +
+	`s:10:"edit_posts";b:1;s:20:"edit_published_posts";b:1;s:13:"publish_posts";b:1;s:4:"read";b:1;` +
+	`s:7:"level_2";b:1;s:7:"level_1";b:1;s:7:"level_0";b:1;s:12:"delete_posts";b:1;` +
+	`s:22:"delete_published_posts";b:1;}}` +
+	`s:11:"contributor";a:2:{s:4:"name";s:11:"Contributor";s:12:"capabilities";a:5:{s:10:"edit_posts";b:1;` +
+	`s:4:"read";b:1;s:7:"level_1";b:1;s:7:"level_0";b:1;s:12:"delete_posts";b:1;}}` +
+	`s:10:"subscriber";a:2:{s:4:"name";s:10:"Subscriber";s:12:"capabilities";a:2:{s:4:"read";b:1;s:7:"level_0";b:1;}}` +
+	`}`
+
+func TestPHPUnserialize_wpUserRoles(t *testing.T) {
+	got, err := PHPUnserialize(wpUserRoles)
+	if err != nil {
+		t.Fatalf("PHPUnserialize() error = %v", err)
+	}
+
+	roles, ok := got.(map[any]any)
+	if !ok || len(roles) != 5 {
+		t.Fatalf("PHPUnserialize() got = %v, want a map of 5 roles", got)
+	}
+
+	want := map[any]any{
+		"name": "Contributor",
+		"capabilities": map[any]any{
+			"edit_posts":   true,
+			"read":         true,
+			"level_1":      true,
+			"level_0":      true,
+			"delete_posts": true,
+		},
+	}
+	if !reflect.DeepEqual(roles["contributor"], want) {
+		t.Errorf("PHPUnserialize() contributor = %v, want %v", roles["contributor"], want)
+	}
+
+	admin := roles["administrator"].(map[any]any)
+	if caps := admin["capabilities"].(map[any]any); len(caps) != 35 || caps["manage_options"] != true {
+		t.Errorf("PHPUnserialize() administrator capabilities = %v", caps)
+	}
+}
+
+func TestPHPUnserialize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			name:  "wp_capabilities",
+			input: `a:1:{s:13:"administrator";b:1;}`,
+			want:  map[any]any{"administrator": true},
+		},
+		{
+			name:  "wp_capabilities with several roles",
+			input: `a:3:{s:6:"editor";b:1;s:15:"bbp_participant";b:1;s:6:"author";b:0;}`,
+			want:  map[any]any{"editor": true, "bbp_participant": true, "author": false},
+		},
+		{
+			name:  "empty wp_capabilities",
+			input: `a:0:{}`,
+			want:  map[any]any{},
+		},
+		{
+			name:  "list",
+			input: `a:2:{i:0;s:4:"read";i:1;i:7;}`,
+			want:  []any{"read", int64(7)},
+		},
+		{
+			name:  "sparse keys",
+			input: `a:2:{i:1;s:1:"a";i:0;s:1:"b";}`,
+			want:  map[any]any{int64(1): "a", int64(0): "b"},
+		},
+		{
+			name:  "scalars",
+			input: `a:4:{s:1:"n";N;s:1:"d";d:-2.5;s:1:"i";i:-3;s:1:"s";s:0:"";}`,
+			want:  map[any]any{"n": nil, "d": -2.5, "i": int64(-3), "s": ""},
+		},
+		{
+			name:  "multibyte string",
+			input: `s:5:"café";`,
+			want:  "café",
+		},
+		{
+			name:  "string containing delimiters",
+			input: `s:7:"a";b:1;";`,
+			want:  `a";b:1;`,
+		},
+		{
+			name:  "object",
+			input: "O:8:\"stdClass\":3:{s:4:\"name\";s:3:\"bob\";s:7:\"\x00*\x00role\";s:5:\"admin\";s:12:\"\x00User\x00secret\";N;}",
+			want:  map[any]any{"name": "bob", "role": "admin", "secret": nil},
+		},
+		{
+			name:    "truncated string",
+			input:   `s:10:"short";`,
+			wantErr: true,
+		},
+		{
+			name:    "truncated array",
+			input:   `a:2:{s:1:"a";b:1;`,
+			wantErr: true,
+		},
+		{
+			name:    "array count larger than the input",
+			input:   `a:2000000000:{}`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			input:   `b:1;b:0;`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid key",
+			input:   `a:1:{b:1;b:1;}`,
+			wantErr: true,
+		},
+		{
+			name:  "nested to the maximum depth",
+			input: strings.Repeat(`a:1:{i:0;`, maxPHPDepth) + `N;` + strings.Repeat(`}`, maxPHPDepth),
+			want:  nestedList(maxPHPDepth),
+		},
+		{
+			name:    "nested too deep",
+			input:   strings.Repeat(`a:1:{i:0;`, maxPHPDepth+1) + `N;` + strings.Repeat(`}`, maxPHPDepth+1),
+			wantErr: true,
+		},
+		{
+			name:    "reference",
+			input:   `a:2:{i:0;s:1:"a";i:1;R:2;}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			input:   ``,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PHPUnserialize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PHPUnserialize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PHPUnserialize() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// nestedList returns a list holding a list, depth times over, around a nil.
+func nestedList(depth int) any {
+	var ret any
+	for i := 0; i < depth; i++ {
+		ret = []any{ret}
+	}
+	return ret
+}
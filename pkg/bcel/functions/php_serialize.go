@@ -1,9 +1,17 @@
 package functions
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/elliotchance/phpserialize"
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // PHPSerializeStringArray serializes a slice of strings into a PHP serialized string.
@@ -62,3 +70,221 @@ func PHPSerializeStringArrayFunc() *FunctionDefinition {
 		},
 	}
 }
+
+// PHPSerialize encodes a value in PHP serialized form. It accepts nil, bools, integers, floats, strings, bytes, and
+// slices and maps of these. Map keys must be strings or integers and are written in sorted order, integers first.
+func PHPSerialize(v any) (string, error) {
+	var sb strings.Builder
+	err := writePHPValue(&sb, v)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func writePHPValue(sb *strings.Builder, v any) error {
+	switch t := v.(type) {
+	case nil:
+		sb.WriteString("N;")
+	case bool:
+		if t {
+			sb.WriteString("b:1;")
+		} else {
+			sb.WriteString("b:0;")
+		}
+	case int:
+		fmt.Fprintf(sb, "i:%d;", t)
+	case int32:
+		fmt.Fprintf(sb, "i:%d;", t)
+	case int64:
+		fmt.Fprintf(sb, "i:%d;", t)
+	case uint64:
+		if t > math.MaxInt64 {
+			return fmt.Errorf("unsigned integer %d overflows a PHP int", t)
+		}
+		fmt.Fprintf(sb, "i:%d;", t)
+	case float64:
+		sb.WriteString("d:")
+		switch {
+		case math.IsInf(t, 1):
+			sb.WriteString("INF")
+		case math.IsInf(t, -1):
+			sb.WriteString("-INF")
+		case math.IsNaN(t):
+			sb.WriteString("NAN")
+		default:
+			sb.WriteString(strconv.FormatFloat(t, 'g', -1, 64))
+		}
+		sb.WriteString(";")
+	case string:
+		writePHPString(sb, t)
+		sb.WriteString(";")
+	case []byte:
+		writePHPString(sb, string(t))
+		sb.WriteString(";")
+	case []string:
+		fmt.Fprintf(sb, "a:%d:{", len(t))
+		for i, e := range t {
+			fmt.Fprintf(sb, "i:%d;", i)
+			writePHPString(sb, e)
+			sb.WriteString(";")
+		}
+		sb.WriteString("}")
+	case []any:
+		fmt.Fprintf(sb, "a:%d:{", len(t))
+		for i, e := range t {
+			fmt.Fprintf(sb, "i:%d;", i)
+			if err := writePHPValue(sb, e); err != nil {
+				return err
+			}
+		}
+		sb.WriteString("}")
+	case map[string]any:
+		m := make(map[any]any, len(t))
+		for k, e := range t {
+			m[k] = e
+		}
+		return writePHPValue(sb, m)
+	case map[any]any:
+		keys := make([]any, 0, len(t))
+		for k := range t {
+			switch k.(type) {
+			case string, int64:
+				keys = append(keys, k)
+			default:
+				return fmt.Errorf("unsupported PHP array key %v of type %T", k, k)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessPHPKey(keys[i], keys[j]) })
+
+		fmt.Fprintf(sb, "a:%d:{", len(t))
+		for _, k := range keys {
+			if err := writePHPValue(sb, k); err != nil {
+				return err
+			}
+			if err := writePHPValue(sb, t[k]); err != nil {
+				return err
+			}
+		}
+		sb.WriteString("}")
+	default:
+		return fmt.Errorf("can not serialize %T as PHP", v)
+	}
+	return nil
+}
+
+func writePHPString(sb *strings.Builder, s string) {
+	fmt.Fprintf(sb, "s:%d:\"%s\"", len(s), s)
+}
+
+// lessPHPKey orders integer keys numerically before string keys.
+func lessPHPKey(a any, b any) bool {
+	ai, aInt := a.(int64)
+	bi, bInt := b.(int64)
+	switch {
+	case aInt && bInt:
+		return ai < bi
+	case aInt != bInt:
+		return aInt
+	default:
+		return a.(string) < b.(string)
+	}
+}
+
+// phpNative converts a CEL value to the Go values PHPSerialize accepts.
+func phpNative(v ref.Val) (any, error) {
+	switch t := v.(type) {
+	case traits.Mapper:
+		m := make(map[any]any)
+		it := t.Iterator()
+		for it.HasNext() == types.True {
+			k := it.Next()
+			key, err := phpNative(k)
+			if err != nil {
+				return nil, err
+			}
+			if u, ok := key.(uint64); ok {
+				if u > math.MaxInt64 {
+					return nil, fmt.Errorf("unsigned key %d overflows a PHP int", u)
+				}
+				key = int64(u)
+			}
+			e, err := phpNative(t.Get(k))
+			if err != nil {
+				return nil, err
+			}
+			m[key] = e
+		}
+		return m, nil
+	case traits.Lister:
+		var l []any
+		it := t.Iterator()
+		for it.HasNext() == types.True {
+			e, err := phpNative(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, e)
+		}
+		return l, nil
+	}
+
+	switch t := v.Value().(type) {
+	case nil, bool, int64, uint64, float64, string, []byte:
+		return t, nil
+	case structpb.NullValue:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("can not serialize %s as PHP", v.Type().TypeName())
+	}
+}
+
+func PHPSerializeFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "phpSerialize",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "phpSerialize_dyn",
+				Args:       []*types.Type{types.DynType},
+				ResultType: types.StringType,
+				Unary: func(v ref.Val) ref.Val {
+					native, err := phpNative(v)
+					if err != nil {
+						return types.NewErr("invalid argument to phpSerialize: %s", err)
+					}
+					result, err := PHPSerialize(native)
+					if err != nil {
+						return types.NewErr("error while serializing PHP value: %s", err)
+					}
+					return types.String(result)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `phpSerialize({'administrator': true})`,
+						Expected: `a:1:{s:13:"administrator";b:1;}`,
+					},
+					{
+						Expr:     `phpSerialize(['read', 'edit_posts'])`,
+						Expected: `a:2:{i:0;s:4:"read";i:1;s:10:"edit_posts";}`,
+					},
+					{
+						Expr:     `phpSerialize({'name': 'Editor', 'capabilities': {'read': true, 'level_7': true}})`,
+						Expected: `a:2:{s:12:"capabilities";a:2:{s:7:"level_7";b:1;s:4:"read";b:1;}s:4:"name";s:6:"Editor";}`,
+					},
+					{
+						Expr:     `phpSerialize(null)`,
+						Expected: `N;`,
+					},
+					{
+						Expr:     `phpSerialize(1.5)`,
+						Expected: `d:1.5;`,
+					},
+					{
+						Expr:     `phpSerialize(phpUnserialize('a:2:{i:3;s:1:"x";s:1:"y";i:-1;}'))`,
+						Expected: `a:2:{i:3;s:1:"x";s:1:"y";i:-1;}`,
+					},
+				},
+			},
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package functions
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -50,3 +51,78 @@ func TestPHPSerializeArray(t *testing.T) {
 		})
 	}
 }
+
+func TestPHPSerialize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   any
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "wp_capabilities",
+			input: map[string]any{"editor": true},
+			want:  `a:1:{s:6:"editor";b:1;}`,
+		},
+		{
+			name: "role",
+			input: map[string]any{
+				"name":         "Subscriber",
+				"capabilities": map[string]any{"read": true, "level_0": true},
+			},
+			want: `a:2:{s:12:"capabilities";a:2:{s:7:"level_0";b:1;s:4:"read";b:1;}s:4:"name";s:10:"Subscriber";}`,
+		},
+		{
+			name:  "mixed keys",
+			input: map[any]any{"b": int64(2), int64(10): "x", int64(2): nil},
+			want:  `a:3:{i:2;N;i:10;s:1:"x";s:1:"b";i:2;}`,
+		},
+		{
+			name:  "list",
+			input: []any{"read", 1.25, false},
+			want:  `a:3:{i:0;s:4:"read";i:1;d:1.25;i:2;b:0;}`,
+		},
+		{
+			name:  "multibyte string",
+			input: "café",
+			want:  `s:5:"café";`,
+		},
+		{
+			name:    "unsupported key",
+			input:   map[any]any{true: "x"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported value",
+			input:   struct{}{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PHPSerialize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PHPSerialize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("PHPSerialize() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPHPSerialize_roundTrip(t *testing.T) {
+	serialized, err := PHPSerialize(map[any]any{"administrator": true, "bbp_keymaster": true})
+	if err != nil {
+		t.Fatalf("PHPSerialize() error = %v", err)
+	}
+	got, err := PHPUnserialize(serialized)
+	if err != nil {
+		t.Fatalf("PHPUnserialize() error = %v", err)
+	}
+	want := map[any]any{"administrator": true, "bbp_keymaster": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package functions
+
+import (
+	"container/list"
+	"regexp"
+	"sync"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// regexCacheSize bounds the number of cached patterns. Patterns normally come from the config, but can also be
+// built from row values, which must not grow the cache without limit.
+const regexCacheSize = 256
+
+// regexCache holds compiled patterns, since expressions are evaluated for every row with the same patterns.
+var regexCache = newRegexLRU(regexCacheSize)
+
+// regexLRU is a cache of compiled patterns that evicts the least recently used pattern once it is full.
+type regexLRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type regexEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRegexLRU(size int) *regexLRU {
+	return &regexLRU{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *regexLRU) get(pattern string) (*regexp.Regexp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[pattern]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*regexEntry).re, true
+}
+
+func (c *regexLRU) add(pattern string, re *regexp.Regexp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[pattern] = c.order.PushFront(&regexEntry{pattern: pattern, re: re})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*regexEntry).pattern)
+	}
+}
+
+func (c *regexLRU) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.get(pattern); ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.add(pattern, re)
+
+	return re, nil
+}
+
+// RegexReplace replaces all matches of pattern in s. The replacement can refer to capture groups as $1 or ${name}.
+func RegexReplace(s string, pattern string, replacement string) (string, error) {
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	return re.ReplaceAllString(s, replacement), nil
+}
+
+// RegexExtract returns the first capture group of the first match of pattern in s, or the whole match if the
+// pattern has no groups. It returns an empty string if there is no match.
+func RegexExtract(s string, pattern string) (string, error) {
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	m := re.FindStringSubmatch(s)
+	switch {
+	case m == nil:
+		return "", nil
+	case len(m) > 1:
+		return m[1], nil
+	default:
+		return m[0], nil
+	}
+}
+
+func RegexReplaceFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "regexReplace",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "regexReplace_string_string_string",
+				Args:       []*types.Type{types.StringType, types.StringType, types.StringType},
+				ResultType: types.StringType,
+				Function: func(args ...ref.Val) ref.Val {
+					s, ok1 := args[0].Value().(string)
+					pattern, ok2 := args[1].Value().(string)
+					replacement, ok3 := args[2].Value().(string)
+					if !ok1 || !ok2 || !ok3 {
+						return types.NewErr("invalid arguments to regexReplace, expected strings")
+					}
+					result, err := RegexReplace(s, pattern, replacement)
+					if err != nil {
+						return types.NewErr("error in regexReplace: %s", err)
+					}
+					return types.String(result)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `regexReplace('CN=Admins,OU=Groups', '^CN=([^,]+),.*$', '$1')`,
+						Expected: "Admins",
+					},
+					{
+						Expr:     `regexReplace('a1b22c333', '[0-9]+', '-')`,
+						Expected: "a-b-c-",
+					},
+					{
+						Expr:     `regexReplace('no match', 'x', 'y')`,
+						Expected: "no match",
+					},
+				},
+			},
+		},
+	}
+}
+
+func RegexExtractFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "regexExtract",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "regexExtract_string_string",
+				Args:       []*types.Type{types.StringType, types.StringType},
+				ResultType: types.StringType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					s, ok1 := lhs.Value().(string)
+					pattern, ok2 := rhs.Value().(string)
+					if !ok1 || !ok2 {
+						return types.NewErr("invalid arguments to regexExtract, expected strings")
+					}
+					result, err := RegexExtract(s, pattern)
+					if err != nil {
+						return types.NewErr("error in regexExtract: %s", err)
+					}
+					return types.String(result)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `regexExtract('alice@example.com', '@(.+)$')`,
+						Expected: "example.com",
+					},
+					{
+						Expr:     `regexExtract('order 1234 shipped', '[0-9]+')`,
+						Expected: "1234",
+					},
+					{
+						Expr:     `regexExtract('none', '[0-9]+')`,
+						Expected: "",
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package functions
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRegexExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{"group", "CN=Admins,OU=Groups", `^CN=([^,]+)`, "Admins", false},
+		{"whole match", "id-123", `[0-9]+`, "123", false},
+		{"first group only", "2024-01-02", `(\d+)-(\d+)`, "2024", false},
+		{"no match", "abc", `[0-9]+`, "", false},
+		{"invalid pattern", "abc", `(`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RegexExtract(tt.s, tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RegexExtract() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RegexExtract() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegexReplace(t *testing.T) {
+	got, err := RegexReplace("alice@example.com", `^(?P<user>[^@]+)@.*$`, "${user}")
+	if err != nil || got != "alice" {
+		t.Errorf("RegexReplace() = %v, %v, want alice", got, err)
+	}
+
+	if _, err := RegexReplace("abc", `[`, ""); err == nil {
+		t.Error("RegexReplace() expected an error for an invalid pattern")
+	}
+}
+
+func TestRegexLRU(t *testing.T) {
+	c := newRegexLRU(2)
+	for _, p := range []string{"a", "b"} {
+		c.add(p, regexp.MustCompile(p))
+	}
+
+	// Using a makes b the least recently used pattern, so adding c evicts b.
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("a is not cached")
+	}
+	c.add("c", regexp.MustCompile("c"))
+
+	if _, ok := c.get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	for _, p := range []string{"a", "c"} {
+		if _, ok := c.get(p); !ok {
+			t.Errorf("%s is not cached", p)
+		}
+	}
+	if c.len() != 2 {
+		t.Errorf("len = %d, want 2", c.len())
+	}
+}
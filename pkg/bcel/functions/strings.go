@@ -0,0 +1,223 @@
+package functions
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// Split splits s around each instance of sep. An empty s returns an empty list.
+func Split(s string, sep string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, sep)
+}
+
+// PadLeft pads s on the left with pad until it is width characters long.
+func PadLeft(s string, width int, pad string) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 || pad == "" {
+		return s
+	}
+
+	padding := strings.Repeat(pad, n/utf8.RuneCountInString(pad)+1)
+	return string([]rune(padding)[:n]) + s
+}
+
+func SplitFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "split",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "split_string_string",
+				Args:       []*types.Type{types.StringType, types.StringType},
+				ResultType: types.NewListType(types.StringType),
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					s, ok1 := lhs.Value().(string)
+					sep, ok2 := rhs.Value().(string)
+					if !ok1 || !ok2 {
+						return types.NewErr("invalid arguments to split, expected strings")
+					}
+					return types.NewStringList(types.DefaultTypeAdapter, Split(s, sep))
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `split('admin,editor', ',')`,
+						Expected: []string{"admin", "editor"},
+					},
+					{
+						Expr:     `split('a.b.c', '.')[2]`,
+						Expected: "c",
+					},
+					{
+						Expr:     `size(split('', ','))`,
+						Expected: int64(0),
+					},
+				},
+			},
+		},
+	}
+}
+
+func JoinFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "join",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "join_list_string",
+				Args:       []*types.Type{types.NewListType(types.StringType), types.StringType},
+				ResultType: types.StringType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					list, ok1 := lhs.(traits.Lister)
+					sep, ok2 := rhs.Value().(string)
+					if !ok1 || !ok2 {
+						return types.NewErr("invalid arguments to join, expected a list of strings and a string")
+					}
+					native, err := list.ConvertToNative(stringListType)
+					if err != nil {
+						return types.NewErr("invalid list argument to join: %s", err)
+					}
+					return types.String(strings.Join(native.([]string), sep))
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `join(['admin', 'editor'], ', ')`,
+						Expected: "admin, editor",
+					},
+					{
+						Expr:     `join(split('a,b', ','), ';')`,
+						Expected: "a;b",
+					},
+					{
+						Expr:     `join([], ',')`,
+						Expected: "",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TrimFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "trim",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "trim_string",
+				Args:       []*types.Type{types.StringType},
+				ResultType: types.StringType,
+				Unary: func(v ref.Val) ref.Val {
+					s, ok := v.Value().(string)
+					if !ok {
+						return types.NewErr("invalid argument to trim, expected string")
+					}
+					return types.String(strings.TrimSpace(s))
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `trim('  alice \t\n')`,
+						Expected: "alice",
+					},
+					{
+						Expr:     `trim(cols["name"])`,
+						Expected: "bob",
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"name": "bob   ",
+							},
+						},
+					},
+				},
+			},
+			{
+				Operator:   "trim_string_string",
+				Args:       []*types.Type{types.StringType, types.StringType},
+				ResultType: types.StringType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					s, ok1 := lhs.Value().(string)
+					cutset, ok2 := rhs.Value().(string)
+					if !ok1 || !ok2 {
+						return types.NewErr("invalid arguments to trim, expected strings")
+					}
+					return types.String(strings.Trim(s, cutset))
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `trim('--slug--', '-')`,
+						Expected: "slug",
+					},
+				},
+			},
+		},
+	}
+}
+
+func padLeftVal(args ...ref.Val) ref.Val {
+	s, ok1 := args[0].Value().(string)
+	width, ok2 := args[1].Value().(int64)
+	if !ok1 || !ok2 {
+		return types.NewErr("invalid arguments to padLeft, expected a string and an int")
+	}
+
+	pad := " "
+	if len(args) > 2 {
+		p, ok := args[2].Value().(string)
+		if !ok {
+			return types.NewErr("invalid pad argument to padLeft, expected string")
+		}
+		pad = p
+	}
+
+	return types.String(PadLeft(s, int(width), pad))
+}
+
+func PadLeftFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "padLeft",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "padLeft_string_int",
+				Args:       []*types.Type{types.StringType, types.IntType},
+				ResultType: types.StringType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					return padLeftVal(lhs, rhs)
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `padLeft('42', 5)`,
+						Expected: "   42",
+					},
+				},
+			},
+			{
+				Operator:   "padLeft_string_int_string",
+				Args:       []*types.Type{types.StringType, types.IntType, types.StringType},
+				ResultType: types.StringType,
+				Function:   padLeftVal,
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `padLeft('42', 6, '0')`,
+						Expected: "000042",
+					},
+					{
+						Expr:     `padLeft(string(cols["id"]), 4, '0')`,
+						Expected: "0007",
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"id": int64(7),
+							},
+						},
+					},
+					{
+						Expr:     `padLeft('toolong', 3, '0')`,
+						Expected: "toolong",
+					},
+				},
+			},
+		},
+	}
+}
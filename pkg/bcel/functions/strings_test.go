@@ -0,0 +1,45 @@
+package functions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPadLeft(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		pad   string
+		want  string
+	}{
+		{"42", 5, "0", "00042"},
+		{"42", 2, "0", "42"},
+		{"42", 1, "0", "42"},
+		{"7", 4, "ab", "aba7"},
+		{"é", 3, "·", "··é"},
+		{"x", 3, "", "x"},
+	}
+	for _, tt := range tests {
+		if got := PadLeft(tt.s, tt.width, tt.pad); got != tt.want {
+			t.Errorf("PadLeft(%q, %d, %q) = %q, want %q", tt.s, tt.width, tt.pad, got, tt.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		s    string
+		sep  string
+		want []string
+	}{
+		{"a,b,c", ",", []string{"a", "b", "c"}},
+		{"a", ",", []string{"a"}},
+		{"", ",", []string{}},
+		{"a,,b", ",", []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		if got := Split(tt.s, tt.sep); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q, %q) = %v, want %v", tt.s, tt.sep, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package functions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// timestampLayouts are the layouts tried, in order, when parsing a timestamp without a layout.
+// They cover RFC 3339 and the DATETIME and DATE formats that SQL drivers return as text.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// namedLayouts lets expressions refer to the standard Go layouts by name.
+var namedLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+func layout(s string) string {
+	if l, ok := namedLayouts[s]; ok {
+		return l
+	}
+	return s
+}
+
+// ParseTimestamp parses s in one of the supported timestamp layouts. Times without a zone are UTC.
+func ParseTimestamp(s string) (time.Time, error) {
+	for _, l := range timestampLayouts {
+		t, err := time.Parse(l, s)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse timestamp %q", s)
+}
+
+// ParseTime parses s with a Go layout, such as "2006-01-02", or the name of one, such as "RFC1123".
+// Times without a zone are UTC.
+func ParseTime(s string, l string) (time.Time, error) {
+	t, err := time.Parse(layout(l), s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+// FormatTime formats t with a Go layout or the name of one.
+func FormatTime(t time.Time, l string) string {
+	return t.Format(layout(l))
+}
+
+// ToTimestamp converts a time, a string in one of the supported layouts, or Unix seconds to a time.
+func ToTimestamp(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC(), nil
+	case string:
+		return ParseTimestamp(t)
+	case []byte:
+		return ParseTimestamp(string(t))
+	case int64:
+		return time.Unix(t, 0).UTC(), nil
+	case uint64:
+		return time.Unix(int64(t), 0).UTC(), nil
+	case float64:
+		return time.UnixMilli(int64(t * 1000)).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to a timestamp", v)
+	}
+}
+
+func ParseTimeFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "parseTime",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "parseTime_string_string",
+				Args:       []*types.Type{types.StringType, types.StringType},
+				ResultType: types.TimestampType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					s, ok := lhs.Value().(string)
+					if !ok {
+						return types.NewErr("invalid argument to parseTime, expected string")
+					}
+					l, ok := rhs.Value().(string)
+					if !ok {
+						return types.NewErr("invalid layout argument to parseTime, expected string")
+					}
+					result, err := ParseTime(s, l)
+					if err != nil {
+						return types.NewErr("error while parsing time: %s", err)
+					}
+					return types.Timestamp{Time: result}
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `parseTime('02/01/2024', '02/01/2006')`,
+						Expected: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+					},
+					{
+						Expr:     `parseTime('Tue, 02 Jan 2024 03:04:05 +0100', 'RFC1123Z')`,
+						Expected: time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC),
+					},
+					{
+						Expr:     `parseTime('2024-01-02', 'DateOnly') < timestamp('2024-01-03T00:00:00Z')`,
+						Expected: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func FormatTimeFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "formatTime",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "formatTime_timestamp_string",
+				Args:       []*types.Type{types.TimestampType, types.StringType},
+				ResultType: types.StringType,
+				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
+					t, ok := lhs.Value().(time.Time)
+					if !ok {
+						return types.NewErr("invalid argument to formatTime, expected timestamp")
+					}
+					l, ok := rhs.Value().(string)
+					if !ok {
+						return types.NewErr("invalid layout argument to formatTime, expected string")
+					}
+					return types.String(FormatTime(t, l))
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `formatTime(timestamp('2024-01-02T03:04:05Z'), 'DateOnly')`,
+						Expected: "2024-01-02",
+					},
+					{
+						Expr:     `formatTime(cols["created_at"], 'RFC3339')`,
+						Expected: "2024-01-02T03:04:05Z",
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"created_at": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func UnixTimeFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "unixTime",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "unixTime_int",
+				Args:       []*types.Type{types.IntType},
+				ResultType: types.TimestampType,
+				Unary: func(v ref.Val) ref.Val {
+					secs, ok := v.Value().(int64)
+					if !ok {
+						return types.NewErr("invalid argument to unixTime, expected int")
+					}
+					return types.Timestamp{Time: time.Unix(secs, 0).UTC()}
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `unixTime(1704164645)`,
+						Expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+					},
+					{
+						Expr:     `unixTime(0) == timestamp('1970-01-01T00:00:00Z')`,
+						Expected: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func ToTimestampFunc() *FunctionDefinition {
+	return &FunctionDefinition{
+		Name: "toTimestamp",
+		Overloads: []*OverloadDefinition{
+			{
+				Operator:   "toTimestamp_dyn",
+				Args:       []*types.Type{types.DynType},
+				ResultType: types.TimestampType,
+				Unary: func(v ref.Val) ref.Val {
+					result, err := ToTimestamp(v.Value())
+					if err != nil {
+						return types.NewErr("error while converting to timestamp: %s", err)
+					}
+					return types.Timestamp{Time: result}
+				},
+				TestCases: []*ExprTestCase{
+					{
+						Expr:     `toTimestamp('2024-01-02 03:04:05')`,
+						Expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+					},
+					{
+						Expr:     `toTimestamp(b'2024-01-02')`,
+						Expected: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+					},
+					{
+						Expr:     `toTimestamp(1704164645)`,
+						Expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+					},
+					{
+						Expr:     `toTimestamp(timestamp('2024-01-02T03:04:05Z'))`,
+						Expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+					},
+					{
+						Expr:     `toTimestamp(cols["last_login"]) > timestamp('2024-01-01T00:00:00Z')`,
+						Expected: true,
+						Inputs: map[string]any{
+							"cols": map[string]any{
+								"last_login": []byte("2024-01-02 03:04:05"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package functions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToTimestamp(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		input   any
+		want    time.Time
+		wantErr bool
+	}{
+		{"time", want.In(time.FixedZone("CET", 3600)), want, false},
+		{"rfc3339", "2024-01-02T04:04:05+01:00", want, false},
+		{"datetime", "2024-01-02 03:04:05", want, false},
+		{"datetime bytes", []byte("2024-01-02 03:04:05"), want, false},
+		{"date", "2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), false},
+		{"unix", int64(1704164645), want, false},
+		{"invalid string", "yesterday", time.Time{}, true},
+		{"invalid type", true, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToTimestamp(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ToTimestamp() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ToTimestamp() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{"RFC3339", "2024-01-02T03:04:05Z"},
+		{"DateOnly", "2024-01-02"},
+		{"02/01/2006 15:04", "02/01/2024 03:04"},
+	}
+	for _, tt := range tests {
+		if got := FormatTime(ts, tt.layout); got != tt.want {
+			t.Errorf("FormatTime(%q) = %v, want %v", tt.layout, got, tt.want)
+		}
+	}
+}
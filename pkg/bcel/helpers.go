@@ -6,15 +6,19 @@ import (
 	"strings"
 )
 
-var dotFieldRegexp = regexp.MustCompile(`\.\w+`)
 var bareStringRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 
 func isAlphaNumeric(c byte) bool {
 	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_'
 }
 
+// isMemberOperand reports whether c can end an expression that a following dot selects a member of.
+func isMemberOperand(c byte) bool {
+	return isAlphaNumeric(c) || c == ')' || c == ']'
+}
+
 // preprocessExpressions replaces all column expressions with the appropriate map access.
-// It also detects 'bare strings' and automatically quotes them.
+// It also detects 'bare strings' and automatically quotes them. String literals are left untouched.
 // Example input: ".role_name == 'Admin'" -> "cols['role_name'] == 'Admin'".
 func preprocessExpressions(expr string) string {
 	if bareStringRegexp.MatchString(expr) {
@@ -25,20 +29,41 @@ func preprocessExpressions(expr string) string {
 		return fmt.Sprintf(`"%s"`, expr)
 	}
 
-	result := expr
-	offset := 0
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
 
-	result = dotFieldRegexp.ReplaceAllStringFunc(result, func(s string) string {
-		matchIndex := strings.Index(expr[offset:], s) + offset
-		if matchIndex > 0 && isAlphaNumeric(expr[matchIndex-1]) {
-			offset = matchIndex + len(s)
-			return s
+		// Copy string literals as is.
+		if quote != 0 {
+			sb.WriteByte(c)
+			if c == '\\' && i+1 < len(expr) {
+				i++
+				sb.WriteByte(expr[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
 		}
 
-		offset = matchIndex + len(s)
-		field := strings.TrimPrefix(s, ".")
-		return fmt.Sprintf("cols['%s']", field)
-	})
+		switch {
+		case c == '\'' || c == '"':
+			quote = c
+			sb.WriteByte(c)
+
+		// A dot after an identifier, a call or an index is a member access, like parseJSON(.attrs).department.
+		case c == '.' && i+1 < len(expr) && isAlphaNumeric(expr[i+1]) && (i == 0 || !isMemberOperand(expr[i-1])):
+			j := i + 1
+			for j < len(expr) && isAlphaNumeric(expr[j]) {
+				j++
+			}
+			fmt.Fprintf(&sb, "cols['%s']", expr[i+1:j])
+			i = j - 1
+
+		default:
+			sb.WriteByte(c)
+		}
+	}
 
-	return result
+	return sb.String()
 }
@@ -32,6 +32,10 @@ func Test_preprocessExpressions(t *testing.T) {
 		{"Quoted string in expression", "user.role == 'admin'", "user.role == 'admin'"},
 		{"Function call with column access", "check_role(.role_name)", "check_role(cols['role_name'])"},
 		{"Bare string with existing quotes", "'alert'", "'alert'"},
+		{"Dot inside a string literal", ".path == '$.roles[0].name'", "cols['path'] == '$.roles[0].name'"},
+		{"Escaped quote inside a string literal", `'it\'s .here' + .name`, `'it\'s .here' + cols['name']`},
+		{"Member access on a call", "parseJSON(.attrs).department", "parseJSON(cols['attrs']).department"},
+		{"Member access on an index", "parseJSON(.attrs).roles[0].name", "parseJSON(cols['attrs']).roles[0].name"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
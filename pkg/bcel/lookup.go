@@ -0,0 +1,54 @@
+package bcel
+
+import (
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// LookupTables provides the rows that the lookup CEL function reads.
+type LookupTables interface {
+	// Lookup returns the row of the named table stored under key, or nil if the table has no such row.
+	// It fails if the table is not defined or has not been loaded.
+	Lookup(name string, key any) (map[string]any, error)
+}
+
+// WithLookupTables sets the tables that lookup('name', key) reads from.
+func WithLookupTables(tables LookupTables) Option {
+	return func(o *envOptions) {
+		o.lookups = tables
+	}
+}
+
+// lookupFunction declares lookup(name, key), which returns the row of a lookup table stored under key as a map,
+// or null if there is none. A null key also returns null.
+func lookupFunction(tables LookupTables) cel.EnvOption {
+	return cel.Function("lookup",
+		cel.Overload(
+			"lookup_string_dyn",
+			[]*cel.Type{cel.StringType, cel.DynType},
+			cel.DynType,
+			cel.BinaryBinding(func(lhs ref.Val, rhs ref.Val) ref.Val {
+				name, ok := lhs.Value().(string)
+				if !ok {
+					return types.NewErr("invalid table argument to lookup, expected string")
+				}
+				if tables == nil {
+					return types.NewErr("lookup table %s is not defined, no lookups are configured", name)
+				}
+				if rhs.Type() == types.NullType {
+					return types.NullValue
+				}
+
+				row, err := tables.Lookup(name, rhs.Value())
+				if err != nil {
+					return types.NewErr("error in lookup: %s", err)
+				}
+				if row == nil {
+					return types.NullValue
+				}
+				return types.DefaultTypeAdapter.NativeToValue(row)
+			}),
+		),
+	)
+}
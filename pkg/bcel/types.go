@@ -0,0 +1,100 @@
+package bcel
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+)
+
+const (
+	resourceTypeName    = "baton.Resource"
+	entitlementTypeName = "baton.Entitlement"
+)
+
+// inputFields declares the fields of the resource, principal and entitlement variables, so that expressions using a
+// field that does not exist fail the type check instead of failing when a row reaches them.
+var inputFields = map[string]map[string]*types.Type{
+	resourceTypeName: {
+		"ID":          types.StringType,
+		"Type":        types.StringType,
+		"DisplayName": types.StringType,
+		"Description": types.StringType,
+		"ParentID":    types.StringType,
+		"ParentType":  types.StringType,
+		"Login":       types.NewNullableType(types.StringType),
+		"Emails":      types.NewListType(types.StringType),
+		"Profile":     types.NewMapType(types.StringType, types.DynType),
+	},
+	entitlementTypeName: {
+		"ID":          types.StringType,
+		"DisplayName": types.StringType,
+		"Slug":        types.StringType,
+		"Description": types.StringType,
+	},
+}
+
+// inputTypes registers the types of inputFields. Values of these types are passed to CEL as map[string]any.
+func inputTypes() cel.EnvOption {
+	return func(env *cel.Env) (*cel.Env, error) {
+		return cel.CustomTypeProvider(&inputProvider{Provider: env.CELTypeProvider()})(env)
+	}
+}
+
+// inputProvider adds the types of inputFields to the provider of the environment.
+type inputProvider struct {
+	types.Provider
+}
+
+func (p *inputProvider) FindStructType(structType string) (*types.Type, bool) {
+	if _, ok := inputFields[structType]; ok {
+		return types.NewTypeTypeWithParam(types.NewObjectType(structType)), true
+	}
+	return p.Provider.FindStructType(structType)
+}
+
+func (p *inputProvider) FindStructFieldNames(structType string) ([]string, bool) {
+	if fields, ok := inputFields[structType]; ok {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, true
+	}
+	return p.Provider.FindStructFieldNames(structType)
+}
+
+func (p *inputProvider) FindStructFieldType(structType, fieldName string) (*types.FieldType, bool) {
+	fields, ok := inputFields[structType]
+	if !ok {
+		return p.Provider.FindStructFieldType(structType, fieldName)
+	}
+
+	t, ok := fields[fieldName]
+	if !ok {
+		return nil, false
+	}
+	return &types.FieldType{
+		Type: t,
+		IsSet: func(target any) bool {
+			m, ok := target.(map[string]any)
+			return ok && m[fieldName] != nil
+		},
+		GetFrom: func(target any) (any, error) {
+			m, ok := target.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("expected %s, got %T", structType, target)
+			}
+			v, ok := m[fieldName]
+			if !ok {
+				return nil, fmt.Errorf("no such field: %s", fieldName)
+			}
+			if v == nil {
+				return types.NullValue, nil
+			}
+			return v, nil
+		},
+	}, true
+}
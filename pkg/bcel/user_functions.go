@@ -0,0 +1,229 @@
+package bcel
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+var identRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// paramTypes maps the type names a function parameter can declare to CEL types.
+var paramTypes = map[string]*cel.Type{
+	"":          cel.DynType,
+	"dyn":       cel.DynType,
+	"string":    cel.StringType,
+	"int":       cel.IntType,
+	"uint":      cel.UintType,
+	"double":    cel.DoubleType,
+	"bool":      cel.BoolType,
+	"bytes":     cel.BytesType,
+	"timestamp": cel.TimestampType,
+	"duration":  cel.DurationType,
+	"list":      cel.ListType(cel.DynType),
+	"map":       cel.MapType(cel.DynType, cel.DynType),
+}
+
+// FunctionDecl declares a CEL function whose body is a CEL expression over its parameters.
+type FunctionDecl struct {
+	Name   string
+	Params []FunctionParam
+	Expr   string
+}
+
+// FunctionParam is a parameter of a declared function. An empty type accepts any value.
+type FunctionParam struct {
+	Name string
+	Type string
+}
+
+// FunctionError is an error in a declared function. Field is the part of the declaration that is wrong: "expr" for
+// the body, "params" for a parameter, or empty.
+type FunctionError struct {
+	Name  string
+	Field string
+	Err   error
+}
+
+func (e *FunctionError) Error() string {
+	return fmt.Sprintf("function %s: %v", e.Name, e.Err)
+}
+
+func (e *FunctionError) Unwrap() error {
+	return e.Err
+}
+
+// WithFunctions adds functions written in CEL to the environment.
+func WithFunctions(decls ...FunctionDecl) Option {
+	return func(o *envOptions) {
+		o.functions = append(o.functions, decls...)
+	}
+}
+
+// compileFunctions type checks the declared functions and returns their declarations. A body can only use its
+// parameters, the built-in functions in base and other declared functions, which are compiled first.
+// Functions that call each other in a cycle are rejected. Errors in a single function are a *FunctionError.
+func compileFunctions(base []cel.EnvOption, decls []FunctionDecl) ([]cel.EnvOption, error) {
+	byName := make(map[string]*FunctionDecl, len(decls))
+	for i := range decls {
+		d := &decls[i]
+		if !identRegex.MatchString(d.Name) {
+			return nil, fmt.Errorf("function name %q is not a valid identifier", d.Name)
+		}
+		if _, ok := byName[d.Name]; ok {
+			return nil, fmt.Errorf("function %s is declared more than once", d.Name)
+		}
+		byName[d.Name] = d
+	}
+
+	order, err := functionOrder(base, decls, byName)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []cel.EnvOption
+	for _, d := range order {
+		opt, err := compileFunction(append(base[:len(base):len(base)], ret...), d)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, opt)
+	}
+
+	return ret, nil
+}
+
+// functionOrder sorts the declared functions so that each one comes after the functions it calls.
+func functionOrder(base []cel.EnvOption, decls []FunctionDecl, byName map[string]*FunctionDecl) ([]*FunctionDecl, error) {
+	parser, err := cel.NewEnv(base...)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make(map[string][]string, len(decls))
+	for _, d := range decls {
+		parsed, iss := parser.Parse(d.Expr)
+		if iss != nil && iss.Err() != nil {
+			return nil, &FunctionError{Name: d.Name, Field: "expr", Err: iss.Err()}
+		}
+
+		seen := make(map[string]bool)
+		ast.PreOrderVisit(ast.NavigateAST(parsed.NativeRep()), ast.NewExprVisitor(func(e ast.Expr) {
+			if e.Kind() != ast.CallKind {
+				return
+			}
+			name := e.AsCall().FunctionName()
+			if _, ok := byName[name]; ok && !seen[name] {
+				seen[name] = true
+				calls[d.Name] = append(calls[d.Name], name)
+			}
+		}))
+		sort.Strings(calls[d.Name])
+	}
+
+	names := make([]string, 0, len(decls))
+	for _, d := range decls {
+		names = append(names, d.Name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(decls))
+	var ret []*FunctionDecl
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("function cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		state[name] = visiting
+		for _, callee := range calls[name] {
+			err := visit(callee, append(path, name))
+			if err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		ret = append(ret, byName[name])
+		return nil
+	}
+	for _, name := range names {
+		err := visit(name, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+func compileFunction(opts []cel.EnvOption, d *FunctionDecl) (cel.EnvOption, error) {
+	argTypes := make([]*cel.Type, 0, len(d.Params))
+	paramNames := make([]string, 0, len(d.Params))
+	seen := make(map[string]bool, len(d.Params))
+	for _, p := range d.Params {
+		if !identRegex.MatchString(p.Name) {
+			return nil, &FunctionError{Name: d.Name, Field: "params", Err: fmt.Errorf("parameter name %q is not a valid identifier", p.Name)}
+		}
+		if seen[p.Name] {
+			return nil, &FunctionError{Name: d.Name, Field: "params", Err: fmt.Errorf("parameter %s is declared more than once", p.Name)}
+		}
+		seen[p.Name] = true
+
+		t, ok := paramTypes[p.Type]
+		if !ok {
+			return nil, &FunctionError{Name: d.Name, Field: "params", Err: fmt.Errorf("parameter %s has unknown type %q", p.Name, p.Type)}
+		}
+		argTypes = append(argTypes, t)
+		paramNames = append(paramNames, p.Name)
+		opts = append(opts, cel.Variable(p.Name, t))
+	}
+
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, &FunctionError{Name: d.Name, Err: err}
+	}
+
+	checked, iss := env.Compile(d.Expr)
+	if iss != nil && iss.Err() != nil {
+		return nil, &FunctionError{Name: d.Name, Field: "expr", Err: iss.Err()}
+	}
+
+	prg, err := env.Program(checked)
+	if err != nil {
+		return nil, &FunctionError{Name: d.Name, Field: "expr", Err: err}
+	}
+
+	name := d.Name
+	return cel.Function(name,
+		cel.Overload(
+			fmt.Sprintf("%s_user_%d", name, len(argTypes)),
+			argTypes,
+			checked.OutputType(),
+			cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+				activation := make(map[string]any, len(args))
+				for i, arg := range args {
+					activation[paramNames[i]] = arg
+				}
+
+				out, _, err := prg.Eval(activation)
+				if err != nil {
+					return types.NewErr("error in %s: %s", name, err)
+				}
+				return out
+			}),
+		),
+	), nil
+}
@@ -0,0 +1,141 @@
+package bcel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv_WithFunctions(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewEnv(ctx, WithFunctions(
+		FunctionDecl{
+			Name:   "wpRole",
+			Params: []FunctionParam{{Name: "role_name"}},
+			Expr:   "phpDeserializeStringArray(string(role_name))[0]",
+		},
+		FunctionDecl{
+			Name:   "wpRoleLabel",
+			Params: []FunctionParam{{Name: "role_name"}},
+			Expr:   "titleCase(wpRole(role_name))",
+		},
+		FunctionDecl{
+			Name:   "fullName",
+			Params: []FunctionParam{{Name: "first", Type: "string"}, {Name: "last", Type: "string"}},
+			Expr:   "first + ' ' + last",
+		},
+		FunctionDecl{
+			Name: "appName",
+			Expr: "'wordpress'",
+		},
+	))
+	require.NoError(t, err)
+
+	inputs := env.SyncInputs(map[string]any{
+		"role_name": []byte(`a:1:{s:6:"editor";b:1;}`),
+		"first":     "Ada",
+		"last":      "Lovelace",
+	})
+
+	out, err := env.EvaluateString(ctx, "wpRole(.role_name)", inputs)
+	require.NoError(t, err)
+	require.Equal(t, "editor", out)
+
+	out, err = env.EvaluateString(ctx, "wpRoleLabel(.role_name)", inputs)
+	require.NoError(t, err)
+	require.Equal(t, "Editor", out)
+
+	out, err = env.EvaluateString(ctx, "fullName(.first, .last) + ' @ ' + appName()", inputs)
+	require.NoError(t, err)
+	require.Equal(t, "Ada Lovelace @ wordpress", out)
+
+	// Typed parameters are checked when the calling expression is compiled.
+	_, err = env.Evaluate(ctx, "fullName('Ada', 1)", inputs)
+	require.ErrorContains(t, err, "found no matching overload for 'fullName'")
+
+	// Runtime errors in the body name the function.
+	_, err = env.Evaluate(ctx, "wpRole('not serialized')", inputs)
+	require.ErrorContains(t, err, "error in wpRole")
+}
+
+func TestEnv_WithFunctions_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		decls   []FunctionDecl
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			decls: []FunctionDecl{
+				{Name: "a", Params: []FunctionParam{{Name: "x"}}, Expr: "b(x)"},
+				{Name: "b", Params: []FunctionParam{{Name: "x"}}, Expr: "c(x)"},
+				{Name: "c", Params: []FunctionParam{{Name: "x"}}, Expr: "a(x)"},
+			},
+			wantErr: "function cycle: a -> b -> c -> a",
+		},
+		{
+			name: "recursion",
+			decls: []FunctionDecl{
+				{Name: "f", Params: []FunctionParam{{Name: "x"}}, Expr: "f(x)"},
+			},
+			wantErr: "function cycle: f -> f",
+		},
+		{
+			name: "type error",
+			decls: []FunctionDecl{
+				{Name: "f", Params: []FunctionParam{{Name: "x", Type: "int"}}, Expr: "x + 'a'"},
+			},
+			wantErr: "function f: ERROR",
+		},
+		{
+			name: "column reference",
+			decls: []FunctionDecl{
+				{Name: "f", Expr: "cols['name']"},
+			},
+			wantErr: "undeclared reference to 'cols'",
+		},
+		{
+			name: "syntax error",
+			decls: []FunctionDecl{
+				{Name: "f", Expr: "1 +"},
+			},
+			wantErr: "function f: ERROR",
+		},
+		{
+			name: "unknown type",
+			decls: []FunctionDecl{
+				{Name: "f", Params: []FunctionParam{{Name: "x", Type: "text"}}, Expr: "x"},
+			},
+			wantErr: `parameter x has unknown type "text"`,
+		},
+		{
+			name: "duplicate parameter",
+			decls: []FunctionDecl{
+				{Name: "f", Params: []FunctionParam{{Name: "x"}, {Name: "x"}}, Expr: "x"},
+			},
+			wantErr: "parameter x is declared more than once",
+		},
+		{
+			name: "invalid name",
+			decls: []FunctionDecl{
+				{Name: "wp-role", Expr: "1"},
+			},
+			wantErr: `function name "wp-role" is not a valid identifier`,
+		},
+		{
+			name: "duplicate name",
+			decls: []FunctionDecl{
+				{Name: "f", Expr: "1"},
+				{Name: "f", Expr: "2"},
+			},
+			wantErr: "function f is declared more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEnv(context.Background(), WithFunctions(tt.decls...))
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package bsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
+)
+
+// celExpression is a CEL expression in the config and the YAML path it was read from.
+type celExpression struct {
+	path string
+	expr string
+}
+
+// CompileExpressions type checks every CEL expression in the config against env and caches their programs, so that
+// mistakes are reported when the connector starts rather than when a row first reaches them. It returns all errors
+// at once, each with the YAML path and line of the expression.
+func (c Config) CompileExpressions(env *bcel.Env) error {
+	var errs []error
+	for _, e := range c.celExpressions() {
+		err := env.Compile(e.expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.location(e.path), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sourceLine is where a YAML value was read from. source is empty for the configuration being parsed, and names the
+// include or preset otherwise.
+type sourceLine struct {
+	source string
+	line   int
+}
+
+// NewEnv creates the CEL environment for the config, with its declared functions and opts. The bodies of the
+// functions are compiled here, so an error in one is reported with the YAML path and line of the function, like the
+// errors of CompileExpressions.
+func (c Config) NewEnv(ctx context.Context, opts ...bcel.Option) (*bcel.Env, error) {
+	env, err := bcel.NewEnv(ctx, append(opts, bcel.WithFunctions(c.FunctionDecls()...))...)
+	if err != nil {
+		var fnErr *bcel.FunctionError
+		if errors.As(err, &fnErr) {
+			return nil, fmt.Errorf("%s: %w", c.location(joinPath("functions", fnErr.Name, fnErr.Field)), fnErr.Err)
+		}
+		return nil, err
+	}
+	return env, nil
+}
+
+// location describes a YAML path, with its line if the config was parsed from YAML, and the file of the line if it
+// was read from an include or preset.
+func (c Config) location(path string) string {
+	l, ok := c.lines[path]
+	switch {
+	case !ok:
+		return path
+	case l.source == "":
+		return fmt.Sprintf("%s (line %d)", path, l.line)
+	default:
+		return fmt.Sprintf("%s (%s, line %d)", path, l.source, l.line)
+	}
+}
+
+// celExpressions returns the CEL expressions of the config in a stable order.
+func (c Config) celExpressions() []celExpression {
+	w := &celWalker{}
+
+	for _, name := range sortedKeys(c.ResourceTypes) {
+		rt := c.ResourceTypes[name]
+		path := joinPath("resource_types", name)
+
+		if rt.List != nil {
+			w.resourceMapping(joinPath(path, "list", "map"), rt.List.Map)
+		}
+
+		if rt.Entitlements != nil {
+			for i, m := range rt.Entitlements.Map {
+				w.entitlementMapping(indexPath(joinPath(path, "entitlements", "map"), i), m, false)
+			}
+		}
+
+		for i, m := range rt.StaticEntitlements {
+			w.entitlementMapping(indexPath(joinPath(path, "static_entitlements"), i), m, true)
+		}
+
+		for i, g := range rt.Grants {
+			if g == nil {
+				continue
+			}
+			for j, m := range g.Map {
+				if m == nil {
+					continue
+				}
+				mp := indexPath(joinPath(indexPath(joinPath(path, "grants"), i), "map"), j)
+				w.add(joinPath(mp, "skip_if"), m.SkipIf)
+				w.add(joinPath(mp, "principal_id"), m.PrincipalId)
+				w.add(joinPath(mp, "entitlement_id"), m.Entitlement)
+			}
+		}
+	}
+
+	if c.Events != nil {
+		for i, m := range c.Events.Map {
+			if m == nil {
+				continue
+			}
+			mp := indexPath("events.map", i)
+			w.add(joinPath(mp, "skip_if"), m.SkipIf)
+			w.add(joinPath(mp, "id"), m.ID)
+			w.add(joinPath(mp, "occurred_at"), m.OccurredAt)
+			w.add(joinPath(mp, "entitlement_id"), m.Entitlement)
+			w.eventResource(joinPath(mp, "target"), m.Target)
+			w.eventResource(joinPath(mp, "actor"), m.Actor)
+			w.eventResource(joinPath(mp, "resource"), m.Resource)
+			w.eventResource(joinPath(mp, "principal"), m.Principal)
+		}
+	}
+
+	return w.exprs
+}
+
+type celWalker struct {
+	exprs []celExpression
+}
+
+func (w *celWalker) add(path string, expr string) {
+	if expr == "" {
+		return
+	}
+	w.exprs = append(w.exprs, celExpression{path: path, expr: expr})
+}
+
+func (w *celWalker) addList(path string, exprs []string) {
+	for i, e := range exprs {
+		w.add(indexPath(path, i), e)
+	}
+}
+
+func (w *celWalker) addMap(path string, exprs map[string]string) {
+	for _, k := range sortedKeys(exprs) {
+		w.add(joinPath(path, k), exprs[k])
+	}
+}
+
+func (w *celWalker) resourceMapping(path string, m *ResourceMapping) {
+	if m == nil {
+		return
+	}
+
+	w.add(joinPath(path, "id"), m.Id)
+	w.add(joinPath(path, "display_name"), m.DisplayName)
+	w.add(joinPath(path, "description"), m.Description)
+
+	t := m.Traits
+	if t == nil {
+		return
+	}
+	path = joinPath(path, "traits")
+
+	if u := t.User; u != nil {
+		up := joinPath(path, "user")
+		w.addList(joinPath(up, "emails"), u.Emails)
+		w.add(joinPath(up, "status"), u.Status)
+		w.add(joinPath(up, "status_details"), u.StatusDetails)
+		w.addMap(joinPath(up, "profile"), u.Profile)
+		w.add(joinPath(up, "account_type"), u.AccountType)
+		w.add(joinPath(up, "login"), u.Login)
+		w.addList(joinPath(up, "login_aliases"), u.LoginAliases)
+		w.add(joinPath(up, "last_login"), u.LastLogin)
+	}
+	if a := t.App; a != nil {
+		w.add(joinPath(path, "app", "help_url"), a.HelpUrl)
+		w.addMap(joinPath(path, "app", "profile"), a.Profile)
+	}
+	if g := t.Group; g != nil {
+		w.addMap(joinPath(path, "group", "profile"), g.Profile)
+	}
+	if r := t.Role; r != nil {
+		w.addMap(joinPath(path, "role", "profile"), r.Profile)
+	}
+	if s := t.Secret; s != nil {
+		sp := joinPath(path, "secret")
+		if s.Owner != nil {
+			w.add(joinPath(sp, "owner", "id"), s.Owner.ID)
+		}
+		w.add(joinPath(sp, "created_at"), s.CreatedAt)
+		w.add(joinPath(sp, "expires_at"), s.ExpiresAt)
+		w.add(joinPath(sp, "last_used_at"), s.LastUsedAt)
+		w.addMap(joinPath(sp, "profile"), s.Profile)
+	}
+}
+
+// entitlementMapping adds the expressions of an entitlement mapping. The ID, slug and purpose of static
+// entitlements are plain values rather than expressions, and static entitlements have no skip_if.
+func (w *celWalker) entitlementMapping(path string, m *EntitlementMapping, static bool) {
+	if m == nil {
+		return
+	}
+
+	if !static {
+		w.add(joinPath(path, "skip_if"), m.SkipIf)
+		w.add(joinPath(path, "id"), m.Id)
+		w.add(joinPath(path, "slug"), m.Slug)
+		w.add(joinPath(path, "purpose"), m.Purpose)
+	}
+	w.add(joinPath(path, "display_name"), m.DisplayName)
+	w.add(joinPath(path, "description"), m.Description)
+
+	p := m.Provisioning
+	if p == nil {
+		return
+	}
+	pp := joinPath(path, "provisioning")
+	w.addMap(joinPath(pp, "vars"), p.Vars)
+	for _, op := range []struct {
+		key     string
+		queries *EntitlementProvisioningQueries
+	}{{"grant", p.Grant}, {"revoke", p.Revoke}} {
+		if op.queries == nil {
+			continue
+		}
+		for i, step := range op.queries.Queries {
+			if step != nil && step.Procedure != nil {
+				w.add(joinPath(indexPath(joinPath(pp, op.key, "queries"), i), "procedure", "success"), step.Procedure.Success)
+			}
+		}
+	}
+}
+
+func (w *celWalker) eventResource(path string, r *EventResource) {
+	if r == nil {
+		return
+	}
+	w.add(joinPath(path, "id"), r.ID)
+	w.add(joinPath(path, "display_name"), r.DisplayName)
+}
+
+func joinPath(path string, keys ...string) string {
+	for _, k := range keys {
+		if path == "" {
+			path = k
+		} else {
+			path += "." + k
+		}
+	}
+	return path
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// nodeLines records the line and source of every value below n by its YAML path, as built by joinPath and indexPath.
+func nodeLines(n *yaml.Node, path string, sources nodeSources, lines map[string]sourceLine) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v := n.Content[i+1]
+			p := joinPath(path, n.Content[i].Value)
+			lines[p] = sourceLine{source: sources[v], line: v.Line}
+			nodeLines(v, p, sources, lines)
+		}
+	case yaml.SequenceNode:
+		for i, e := range n.Content {
+			p := indexPath(path, i)
+			lines[p] = sourceLine{source: sources[e], line: e.Line}
+			nodeLines(e, p, sources, lines)
+		}
+	case yaml.AliasNode:
+		if n.Alias != nil {
+			nodeLines(n.Alias, path, sources, lines)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package bsql
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
+)
+
+func TestConfig_CompileExpressions_Examples(t *testing.T) {
+	for _, name := range []string{"example", "wordpress", "bitmask", "oracle", "oracle-test"} {
+		t.Run(name, func(t *testing.T) {
+			c, err := Parse([]byte(loadExampleConfig(t, name)))
+			require.NoError(t, err)
+
+			env, err := c.NewEnv(context.Background())
+			require.NoError(t, err)
+
+			require.NotEmpty(t, c.celExpressions())
+			require.NoError(t, c.CompileExpressions(env))
+		})
+	}
+}
+
+func TestConfig_CompileExpressions_Errors(t *testing.T) {
+	c, err := Parse([]byte(`
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT id, name FROM users
+      map:
+        id: .id
+        display_name: .name +
+        traits:
+          user:
+            emails:
+              - .email
+              - unknownFunc(.email)
+  group:
+    name: Group
+    list:
+      query: SELECT id FROM groups
+      map:
+        id: .id
+        display_name: .id
+    grants:
+      - query: SELECT user_id FROM members
+        map:
+          - principal_id: .user_id
+            principal_type: user
+            entitlement_id: member
+            skip_if: missing.field == 1
+`))
+	require.NoError(t, err)
+
+	env, err := bcel.NewEnv(context.Background())
+	require.NoError(t, err)
+
+	err = c.CompileExpressions(env)
+	require.Error(t, err)
+
+	msg := err.Error()
+	require.Contains(t, msg, "resource_types.group.grants[0].map[0].skip_if (line 28):")
+	require.Contains(t, msg, "resource_types.user.list.map.display_name (line 9):")
+	require.Contains(t, msg, "resource_types.user.list.map.traits.user.emails[1] (line 14):")
+	require.NotContains(t, msg, "emails[0]")
+	require.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 3)
+}
+
+func TestConfig_CompileExpressions_IncludedFiles(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"app.yml": `
+include: users.yml
+resource_types:
+  group:
+    name: Group
+    list:
+      query: SELECT id FROM groups
+      map:
+        id: .id
+        display_name: .id +
+`,
+		"users.yml": `
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT id FROM users
+      map:
+        id: .id
+        display_name: .name +
+`,
+	})
+
+	c, err := LoadConfigFromFile(filepath.Join(dir, "app.yml"))
+	require.NoError(t, err)
+
+	env, err := c.NewEnv(context.Background())
+	require.NoError(t, err)
+
+	msg := c.CompileExpressions(env).Error()
+	require.Contains(t, msg, "resource_types.group.list.map.display_name (line 10):")
+	require.Contains(t, msg, "resource_types.user.list.map.display_name ("+filepath.Join(dir, "users.yml")+", line 9):")
+}
+
+func TestConfig_NewEnv_FunctionErrors(t *testing.T) {
+	c, err := Parse([]byte(`
+functions:
+  fullName:
+    params: [first, last]
+    expr: first + ' ' + lst
+`))
+	require.NoError(t, err)
+
+	_, err = c.NewEnv(context.Background())
+	require.ErrorContains(t, err, "functions.fullName.expr (line 5):")
+	require.ErrorContains(t, err, "undeclared reference to 'lst'")
+
+	c, err = Parse([]byte(`
+functions:
+  fullName:
+    params:
+      - name: first
+        type: text
+    expr: first
+`))
+	require.NoError(t, err)
+
+	_, err = c.NewEnv(context.Background())
+	require.ErrorContains(t, err, `functions.fullName.params (line 5): parameter first has unknown type "text"`)
+}
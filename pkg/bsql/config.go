@@ -1,11 +1,22 @@
 package bsql
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"filippo.io/age"
 	"gopkg.in/yaml.v3"
 
 	connector_v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
+	"github.com/conductorone/baton-sql/pkg/database"
 )
 
 // Config represents the overall connector configuration.
@@ -16,15 +27,84 @@ type Config struct {
 	// AppDescription provides an optional description of the application.
 	AppDescription string `yaml:"app_description" json:"app_description"`
 
+	// AllowUnquoted permits the ?<name|unquoted> query token option, which writes values into SQL without any
+	// quoting or validation. It is off by default; use the ident or keyword options instead where possible.
+	AllowUnquoted bool `yaml:"allow_unquoted,omitempty" json:"allow_unquoted,omitempty"`
+
+	// Preset selects a built-in configuration, such as "oracle-native", that this configuration extends.
+	Preset string `yaml:"preset,omitempty" json:"preset,omitempty"`
+
 	// Connect holds the database connection configuration including DSN and credentials.
 	Connect DatabaseConfig `yaml:"connect" json:"connect"`
 
 	// ResourceTypes defines the set of resource types (e.g., user, role) configured in the connector.
 	ResourceTypes map[string]ResourceType `yaml:"resource_types" json:"resource_types"`
+
+	// Events optionally defines an event feed read from an audit or changelog table.
+	Events *EventsConfig `yaml:"events,omitempty" json:"events,omitempty"`
+
+	// Functions declares CEL functions written in CEL, so mappings can share long expressions, e.g. wpRole(.role_name).
+	Functions map[string]*FunctionConfig `yaml:"functions,omitempty" json:"functions,omitempty"`
+
+	// Lookups defines named reference tables that are loaded into memory and read from CEL with lookup('name', key).
+	Lookups map[string]*LookupConfig `yaml:"lookups,omitempty" json:"lookups,omitempty"`
+
+	// StatePath is the local file that stores incremental sync watermarks between runs.
+	// It is required when any query has incremental sync configured.
+	StatePath string `yaml:"state_path,omitempty" json:"state_path,omitempty"`
+
+	// ExperimentalIncrementalSync permits incremental queries. The SDK has no partial sync yet, so a sync that
+	// skipped unchanged rows would record them as deleted. Until it does, incremental queries still read every row,
+	// with ?<Since> bound to the zero value, and only their watermarks are tracked.
+	ExperimentalIncrementalSync bool `yaml:"experimental_incremental_sync,omitempty" json:"experimental_incremental_sync,omitempty"`
+
+	// lines maps YAML paths to the line they were read from, for error messages.
+	lines map[string]sourceLine
+
+	// identities decrypt the ENC[...] connection settings on connect.
+	identities []age.Identity
 }
 
 // DatabaseConfig contains settings required to connect to the database.
 type DatabaseConfig struct {
+	// ConnectionConfig is the default connection, used for both sync and provisioning unless overridden by Read or Write.
+	ConnectionConfig `yaml:",inline"`
+
+	// Read optionally overrides the connection used for sync queries, e.g. to point heavy reads at a replica.
+	Read *ConnectionConfig `yaml:"read,omitempty" json:"read,omitempty"`
+
+	// Write optionally overrides the connection used for provisioning, e.g. to always target the primary.
+	Write *ConnectionConfig `yaml:"write,omitempty" json:"write,omitempty"`
+
+	// Snapshot runs all sync queries inside a single read-only transaction so the whole sync sees one
+	// consistent point in time. Each sync starts a new snapshot.
+	Snapshot *SnapshotConfig `yaml:"snapshot,omitempty" json:"snapshot,omitempty"`
+
+	// Pool configures the connection pool. It applies to both the read and the write connection.
+	Pool *PoolConfig `yaml:"pool,omitempty" json:"pool,omitempty"`
+
+	// Connections defines additional named connections, possibly to other database engines.
+	// Queries select one with their `connection` key. Queries without one use the default connection above.
+	Connections map[string]*DatabaseConfig `yaml:"connections,omitempty" json:"connections,omitempty"`
+}
+
+// PoolConfig configures the database connection pool. Unset values use the defaults.
+type PoolConfig struct {
+	// MaxOpenConns is the maximum number of open connections. Defaults to 10.
+	MaxOpenConns int `yaml:"max_open_conns,omitempty" json:"max_open_conns,omitempty"`
+
+	// MaxIdleConns is the maximum number of idle connections kept in the pool. Defaults to 10.
+	MaxIdleConns int `yaml:"max_idle_conns,omitempty" json:"max_idle_conns,omitempty"`
+
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused (e.g. "5m"). Defaults to 5 minutes.
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime,omitempty" json:"conn_max_lifetime,omitempty"`
+
+	// ConnMaxIdleTime is the maximum amount of time a connection may sit idle before it is closed (e.g. "1m").
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time,omitempty" json:"conn_max_idle_time,omitempty"`
+}
+
+// ConnectionConfig holds the DSN and credentials for a single database connection.
+type ConnectionConfig struct {
 	// DSN is the Database Source Name connection string used to establish the database connection.
 	DSN string `yaml:"dsn" json:"dsn"`
 
@@ -38,6 +118,127 @@ type DatabaseConfig struct {
 	Password string `yaml:"password" json:"password"`
 }
 
+// IsSet reports whether the default connection is configured.
+func (d DatabaseConfig) IsSet() bool {
+	return d.DSN != "" || d.Read != nil || d.Write != nil
+}
+
+// ReadConnection returns the connection that sync queries should use.
+func (d DatabaseConfig) ReadConnection() (ConnectionConfig, error) {
+	if d.Read != nil {
+		return *d.Read, nil
+	}
+	if d.DSN == "" {
+		return ConnectionConfig{}, errors.New("connect: dsn or connect.read.dsn must be set")
+	}
+	return d.ConnectionConfig, nil
+}
+
+// WriteConnection returns the connection that provisioning queries should use.
+func (d DatabaseConfig) WriteConnection() (ConnectionConfig, error) {
+	if d.Write != nil {
+		return *d.Write, nil
+	}
+	if d.DSN == "" {
+		return ConnectionConfig{}, errors.New("connect: dsn or connect.write.dsn must be set")
+	}
+	return d.ConnectionConfig, nil
+}
+
+// writeEngine returns the engine of the connection that provisioning on the named connection writes to, or
+// database.Unknown if its DSN does not tell.
+func (c *Config) writeEngine(name string) database.DbEngine {
+	d := &c.Connect
+	if name != "" {
+		d = c.Connect.Connections[name]
+		if d == nil {
+			return database.Unknown
+		}
+	}
+	conn, err := d.WriteConnection()
+	if err != nil {
+		return database.Unknown
+	}
+	return database.EngineFromDSN(conn.DSN)
+}
+
+// UsedConnections returns the names of the connections that queries in the config refer to, split into those used
+// by sync queries and those used for provisioning. The default connection is reported with an empty name.
+func (c Config) UsedConnections() (map[string]bool, map[string]bool) {
+	syncConns := make(map[string]bool)
+	provisioningConns := make(map[string]bool)
+
+	addProvisioning := func(e *EntitlementMapping) {
+		if e != nil && e.Provisioning != nil {
+			provisioningConns[e.Provisioning.Connection] = true
+		}
+	}
+
+	if c.Events != nil {
+		syncConns[c.Events.Connection] = true
+	}
+
+	for _, l := range c.Lookups {
+		if l != nil {
+			syncConns[l.Connection] = true
+		}
+	}
+
+	for _, rt := range c.ResourceTypes {
+		if rt.List != nil {
+			syncConns[rt.List.Connection] = true
+		}
+		if rt.Entitlements != nil {
+			syncConns[rt.Entitlements.Connection] = true
+			for _, e := range rt.Entitlements.Map {
+				addProvisioning(e)
+			}
+		}
+		for _, g := range rt.Grants {
+			if g != nil {
+				syncConns[g.Connection] = true
+			}
+		}
+		for _, e := range rt.StaticEntitlements {
+			addProvisioning(e)
+		}
+	}
+
+	return syncConns, provisioningConns
+}
+
+// UsesIncrementalSync reports whether any query in the config has incremental sync configured.
+func (c Config) UsesIncrementalSync() bool {
+	for _, rt := range c.ResourceTypes {
+		if rt.List != nil && rt.List.Incremental != nil {
+			return true
+		}
+		for _, g := range rt.Grants {
+			if g != nil && g.Incremental != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SnapshotConfig configures the transaction used for sync queries. It is read-only except on SQL Server, whose
+// driver does not support read-only transactions.
+type SnapshotConfig struct {
+	// Enabled turns on running sync queries inside a consistent snapshot.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// IsolationLevel overrides the engine default isolation level of the snapshot transaction.
+	// By default MySQL uses repeatable_read, SQL Server uses snapshot, and Oracle uses a read-only transaction.
+	// Supported values are: read_committed, repeatable_read, snapshot, serializable
+	// Oracle only supports read_committed and serializable.
+	IsolationLevel string `yaml:"isolation_level,omitempty" json:"isolation_level,omitempty"`
+
+	// MaxAge replaces the snapshot with a fresh one once it is older than this duration (e.g. "1h"), even in the
+	// middle of a sync. This bounds how long a single transaction stays open during very long syncs.
+	MaxAge time.Duration `yaml:"max_age,omitempty" json:"max_age,omitempty"`
+}
+
 // ResourceType defines configuration for a specific type of resource.
 type ResourceType struct {
 	// Name is the display name for this resource type.
@@ -67,9 +268,22 @@ type ListQuery struct {
 	// Query is the SQL statement used to fetch a list of resources.
 	Query string `yaml:"query" json:"query"`
 
+	// Connection names the connection from connect.connections to run the query on. Defaults to the default connection.
+	Connection string `yaml:"connection,omitempty" json:"connection,omitempty"`
+
 	// Pagination defines the pagination strategy and settings for the list query.
 	Pagination *Pagination `yaml:"pagination" json:"pagination"`
 
+	// Timeout bounds how long the query may run for a single page, including processing its rows (e.g. "30s").
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Columns overrides how the values of the named columns are converted before CEL sees them: raw, string, int,
+	// number, double, bool, timestamp, bytes or uuid.
+	Columns map[string]string `yaml:"columns,omitempty" json:"columns,omitempty"`
+
+	// Incremental, if set, only reads rows changed since the last sync using the ?<Since> query token.
+	Incremental *IncrementalSync `yaml:"incremental,omitempty" json:"incremental,omitempty"`
+
 	// Map specifies how to map raw query columns to standardized resource fields.
 	Map *ResourceMapping `yaml:"map" json:"map"`
 }
@@ -114,6 +328,9 @@ type Traits struct {
 
 	// User contains trait mappings for user resources.
 	User *UserTraitMapping `yaml:"user" json:"user"`
+
+	// Secret contains trait mappings for secret resources, such as API keys and service tokens.
+	Secret *SecretTraitMapping `yaml:"secret,omitempty" json:"secret,omitempty"`
 }
 
 // UserTraitMapping defines attribute mappings specifically for user resources.
@@ -129,6 +346,14 @@ type UserTraitMapping struct {
 	// Deleted: deleted
 	Status string `yaml:"status" json:"status"`
 
+	// StatusValues maps additional status values to enabled, disabled, deleted or unspecified, e.g. "0": disabled.
+	// Values are matched case-insensitively and take precedence over the supported values above.
+	StatusValues map[string]string `yaml:"status_values,omitempty" json:"status_values,omitempty"`
+
+	// UnmappedStatus is the status used for values that are not mapped, or "error" to fail the sync.
+	// When unset, unmapped values log a warning and the status is unspecified.
+	UnmappedStatus string `yaml:"unmapped_status,omitempty" json:"unmapped_status,omitempty"`
+
 	// StatusDetails provides additional information about the user's status.
 	StatusDetails string `yaml:"status_details" json:"status_details"`
 
@@ -139,13 +364,25 @@ type UserTraitMapping struct {
 	// Supported values are: user, human, service, system
 	AccountType string `yaml:"account_type" json:"account_type"`
 
+	// AccountTypeValues maps additional account type values to human, service, system or unspecified, e.g. "T": service.
+	// Values are matched case-insensitively and take precedence over the supported values above.
+	AccountTypeValues map[string]string `yaml:"account_type_values,omitempty" json:"account_type_values,omitempty"`
+
+	// UnmappedAccountType is the account type used for values that are not mapped, or "error" to fail the sync.
+	// When unset, unmapped values log a warning and the account type is human.
+	UnmappedAccountType string `yaml:"unmapped_account_type,omitempty" json:"unmapped_account_type,omitempty"`
+
+	// ReplaceBuiltinValues drops the supported status and account type values, so that only StatusValues and
+	// AccountTypeValues are recognized.
+	ReplaceBuiltinValues bool `yaml:"replace_builtin_values,omitempty" json:"replace_builtin_values,omitempty"`
+
 	// Login is the user's primary login identifier.
 	Login string `yaml:"login" json:"login"`
 
 	// LoginAliases lists alternative login identifiers for the user.
 	LoginAliases []string `yaml:"login_aliases" json:"login_aliases"`
 
-	// LastLogin records the time of the user's last login.
+	// LastLogin records the time of the user's last login, as a timestamp or a string. Null values are skipped.
 	LastLogin string `yaml:"last_login" json:"last_login"`
 
 	// MfaEnabled indicates whether multi-factor authentication is enabled for the user.
@@ -176,6 +413,34 @@ type RoleTraitMapping struct {
 	Profile map[string]string `yaml:"profile" json:"profile"`
 }
 
+// SecretTraitMapping defines attribute mappings for secret resources.
+// The time fields are CEL expressions evaluating to a timestamp or a string; empty and null values are skipped.
+type SecretTraitMapping struct {
+	// Owner identifies the resource the secret belongs to, usually a user.
+	Owner *SecretOwner `yaml:"owner,omitempty" json:"owner,omitempty"`
+
+	// CreatedAt is when the secret was created.
+	CreatedAt string `yaml:"created_at,omitempty" json:"created_at,omitempty"`
+
+	// ExpiresAt is when the secret expires.
+	ExpiresAt string `yaml:"expires_at,omitempty" json:"expires_at,omitempty"`
+
+	// LastUsedAt is when the secret was last used.
+	LastUsedAt string `yaml:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+
+	// Profile is a set of key-value pairs representing secret attributes.
+	Profile map[string]string `yaml:"profile,omitempty" json:"profile,omitempty"`
+}
+
+// SecretOwner identifies the resource that owns a secret.
+type SecretOwner struct {
+	// ResourceType is the resource type ID of the owner. Defaults to user.
+	ResourceType string `yaml:"resource_type,omitempty" json:"resource_type,omitempty"`
+
+	// ID is a CEL expression for the owner's resource ID. Empty and null values leave the secret without an owner.
+	ID string `yaml:"id" json:"id"`
+}
+
 // Pagination defines how query results should be paginated.
 type Pagination struct {
 	// Strategy defines the pagination approach, e.g., "offset" or "cursor".
@@ -183,16 +448,49 @@ type Pagination struct {
 
 	// PrimaryKey is the column used to uniquely identify records for pagination purposes.
 	PrimaryKey string `yaml:"primary_key,omitempty" json:"primary_key,omitempty"`
+
+	// CursorType is the type the ?<Cursor> token is bound as: "string" (default), "integer" or "timestamp".
+	// On the first page it is bound to the zero value of the type: '', 0 or 1970-01-01. Oracle treats '' as NULL,
+	// so queries on Oracle should use a typed cursor.
+	CursorType string `yaml:"cursor_type,omitempty" json:"cursor_type,omitempty"`
+}
+
+// IncrementalSync configures watermark based incremental reads of a query.
+//
+// The query filters on the ?<Since> token, for example "WHERE updated_at >= ?<Since>", and the highest value of
+// Column seen by a completed read is stored in the state file as the next watermark. Incremental reads only return
+// changed rows, so deletes are only picked up by the periodic full read, which binds ?<Since> to the zero value.
+// Until the SDK supports partial syncs, every read is a full read (see Config.ExperimentalIncrementalSync).
+type IncrementalSync struct {
+	// Column is the result column holding the watermark, usually a last-modified timestamp.
+	Column string `yaml:"column" json:"column"`
+
+	// Type is the watermark type, either "timestamp" (default) or "integer".
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+
+	// FullSyncInterval is how often a full read runs instead of an incremental one. Defaults to 24h.
+	FullSyncInterval time.Duration `yaml:"full_sync_interval,omitempty" json:"full_sync_interval,omitempty"`
 }
 
 // EntitlementsQuery defines the structure for querying dynamic entitlements.
 type EntitlementsQuery struct {
-	// Query is the SQL statement used to fetch dynamic entitlements.
+	// Query is the SQL statement used to fetch dynamic entitlements. The ?<Resource_ID> token is bound to the ID of
+	// the resource whose entitlements are listed.
 	Query string `yaml:"query" json:"query"`
 
+	// Connection names the connection from connect.connections to run the query on. Defaults to the default connection.
+	Connection string `yaml:"connection,omitempty" json:"connection,omitempty"`
+
 	// Pagination defines how pagination should be handled for the entitlements query.
 	Pagination *Pagination `yaml:"pagination" json:"pagination"`
 
+	// Timeout bounds how long the query may run for a single page, including processing its rows (e.g. "30s").
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Columns overrides how the values of the named columns are converted before CEL sees them: raw, string, int,
+	// number, double, bool, timestamp, bytes or uuid.
+	Columns map[string]string `yaml:"columns,omitempty" json:"columns,omitempty"`
+
 	// Map contains mappings that interpret query results as entitlement objects.
 	Map []*EntitlementMapping `yaml:"map" json:"map"`
 }
@@ -238,6 +536,14 @@ type EntitlementProvisioning struct {
 
 	// Vars provides variables that can be used within provisioning SQL queries.
 	Vars map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+
+	// Keywords lists, per var, the values the ?<var|keyword> token option accepts, e.g. the privileges that may be
+	// granted. A var used with the keyword option must have a list here.
+	Keywords map[string][]string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+
+	// Connection names the connection from connect.connections that the grant and revoke queries run on.
+	// Defaults to the default connection.
+	Connection string `yaml:"connection,omitempty" json:"connection,omitempty"`
 }
 
 // EntitlementProvisioningQueries defines the SQL statements used for entitlement provisioning operations.
@@ -245,18 +551,138 @@ type EntitlementProvisioningQueries struct {
 	// NoTransaction indicates whether the provisioning queries should be executed without a transaction.
 	NoTransaction bool `yaml:"no_transaction,omitempty" json:"no_transaction,omitempty"`
 
-	// Queries is a list of SQL statements to execute for the provisioning operation.
-	Queries []string `yaml:"queries,omitempty" json:"queries,omitempty"`
+	// Queries is a list of steps to execute for the provisioning operation. Each step is either a SQL statement
+	// or a stored procedure call.
+	Queries []*ProvisioningStep `yaml:"queries,omitempty" json:"queries,omitempty"`
+
+	// IsolationLevel sets the isolation level of the provisioning transaction.
+	// Supported values are: read_uncommitted, read_committed, repeatable_read, snapshot, serializable
+	// Oracle only supports read_committed and serializable. A level the engine of the connection does not support
+	// fails the config load.
+	IsolationLevel string `yaml:"isolation_level,omitempty" json:"isolation_level,omitempty"`
+
+	// Timeout bounds how long each attempt at running the provisioning queries may take (e.g. "30s").
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Retry configures retrying the provisioning queries when the database reports a deadlock or lock timeout.
+	Retry *RetryConfig `yaml:"retry,omitempty" json:"retry,omitempty"`
+}
+
+// ProvisioningStep is a single provisioning step. In YAML and JSON a plain string is a SQL statement.
+type ProvisioningStep struct {
+	// Query is a SQL statement.
+	Query string `yaml:"query,omitempty" json:"query,omitempty"`
+
+	// Procedure calls a stored procedure instead of running a SQL statement.
+	Procedure *ProcedureCall `yaml:"procedure,omitempty" json:"procedure,omitempty"`
+}
+
+// UnmarshalYAML accepts either a SQL statement string or a step mapping.
+func (p *ProvisioningStep) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		p.Query = value.Value
+		return nil
+	}
+
+	type plain ProvisioningStep
+	err := value.Decode((*plain)(p))
+	if err != nil {
+		return err
+	}
+
+	if (p.Query == "") == (p.Procedure == nil) {
+		return fmt.Errorf("line %d: a provisioning step needs exactly one of query or procedure", value.Line)
+	}
+
+	return nil
+}
+
+// UnmarshalJSON accepts either a SQL statement string or a step object.
+func (p *ProvisioningStep) UnmarshalJSON(data []byte) error {
+	var query string
+	if json.Unmarshal(data, &query) == nil {
+		p.Query = query
+		return nil
+	}
+
+	type plain ProvisioningStep
+	err := json.Unmarshal(data, (*plain)(p))
+	if err != nil {
+		return err
+	}
+
+	if (p.Query == "") == (p.Procedure == nil) {
+		return errors.New("a provisioning step needs exactly one of query or procedure")
+	}
+
+	return nil
+}
+
+// ProcedureCall defines a stored procedure call used for provisioning.
+type ProcedureCall struct {
+	// Name is the name of the procedure, optionally qualified, e.g. "dbo.sp_grant_role" or "PKG_SECURITY.GRANT_ROLE".
+	Name string `yaml:"name" json:"name"`
+
+	// Params lists the procedure parameters. MySQL passes them by position, so they must be in declaration order.
+	Params []*ProcedureParam `yaml:"params,omitempty" json:"params,omitempty"`
+
+	// Success is a CEL expression that decides if the call succeeded. It can use the provisioning vars, including
+	// OUT parameter values, and on SQL Server the procedure's return code as .return_status.
+	// By default a SQL Server call succeeds when the return code is 0, and calls on other engines succeed unless they
+	// raise an error.
+	Success string `yaml:"success,omitempty" json:"success,omitempty"`
+}
+
+// ProcedureParam defines a stored procedure parameter.
+type ProcedureParam struct {
+	// Name is the parameter name as declared by the procedure.
+	Name string `yaml:"name" json:"name"`
+
+	// Var names the provisioning var holding the value of IN and INOUT parameters, and the var that receives the
+	// value of OUT and INOUT parameters. Defaults to Name.
+	Var string `yaml:"var,omitempty" json:"var,omitempty"`
+
+	// Direction is "in" (default), "out" or "inout".
+	Direction string `yaml:"direction,omitempty" json:"direction,omitempty"`
+
+	// Type is the type of OUT values: "string" (default), "int", "float", "bool" or "time".
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+}
+
+// RetryConfig defines how operations are retried after a retryable database error.
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first one. Defaults to 3.
+	MaxAttempts int `yaml:"max_attempts,omitempty" json:"max_attempts,omitempty"`
+
+	// InitialBackoff is the delay before the first retry. It doubles after every attempt. Defaults to 100ms.
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty" json:"initial_backoff,omitempty"`
+
+	// MaxBackoff caps the delay between attempts. Defaults to 5s.
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty" json:"max_backoff,omitempty"`
 }
 
 // GrantsQuery defines the structure for querying existing entitlement grants.
 type GrantsQuery struct {
-	// Query is the SQL statement used to retrieve existing entitlement grants.
+	// Query is the SQL statement used to retrieve existing entitlement grants. The ?<Resource_ID> token is bound to
+	// the ID of the resource whose grants are listed.
 	Query string `yaml:"query" json:"query"`
 
+	// Connection names the connection from connect.connections to run the query on. Defaults to the default connection.
+	Connection string `yaml:"connection,omitempty" json:"connection,omitempty"`
+
 	// Pagination defines how to paginate through the results of the grants query.
 	Pagination *Pagination `yaml:"pagination" json:"pagination"`
 
+	// Timeout bounds how long the query may run for a single page, including processing its rows (e.g. "30s").
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Columns overrides how the values of the named columns are converted before CEL sees them: raw, string, int,
+	// number, double, bool, timestamp, bytes or uuid.
+	Columns map[string]string `yaml:"columns,omitempty" json:"columns,omitempty"`
+
+	// Incremental, if set, only reads rows changed since the last sync using the ?<Since> query token.
+	Incremental *IncrementalSync `yaml:"incremental,omitempty" json:"incremental,omitempty"`
+
 	// Map contains mappings to interpret each row of the query result as a grant.
 	Map []*GrantMapping `yaml:"map" json:"map"`
 }
@@ -279,29 +705,458 @@ type GrantMapping struct {
 	Annotations *Annotations `yaml:"annotations" json:"annotations"`
 }
 
+// FunctionConfig declares a CEL function whose body is a CEL expression over its parameters.
+// Bodies cannot read columns or other inputs directly, only through parameters.
+type FunctionConfig struct {
+	// Params lists the parameters in call order.
+	Params []*FunctionParam `yaml:"params,omitempty" json:"params,omitempty"`
+
+	// Expr is the CEL expression that computes the result, e.g. "phpDeserializeStringArray(string(role_name))[0]".
+	Expr string `yaml:"expr" json:"expr"`
+}
+
+// FunctionParam is a parameter of a declared function. In YAML a plain string is the name of a parameter of any type.
+type FunctionParam struct {
+	// Name is the name the body uses for the parameter.
+	Name string `yaml:"name" json:"name"`
+
+	// Type optionally restricts the argument to one of: string, int, uint, double, bool, bytes, timestamp, duration,
+	// list, map or dyn. Calls with arguments of another type fail when the expression is compiled.
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+}
+
+// UnmarshalYAML accepts either a parameter name or a parameter mapping.
+func (p *FunctionParam) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		p.Name = value.Value
+		return nil
+	}
+
+	type plain FunctionParam
+	return value.Decode((*plain)(p))
+}
+
+// FunctionDecls returns the declared functions for the CEL environment, sorted by name.
+func (c Config) FunctionDecls() []bcel.FunctionDecl {
+	ret := make([]bcel.FunctionDecl, 0, len(c.Functions))
+	for name, fn := range c.Functions {
+		d := bcel.FunctionDecl{Name: name}
+		if fn != nil {
+			d.Expr = fn.Expr
+			for _, p := range fn.Params {
+				if p != nil {
+					d.Params = append(d.Params, bcel.FunctionParam{Name: p.Name, Type: p.Type})
+				}
+			}
+		}
+		ret = append(ret, d)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+// LookupConfig defines a reference table, such as departments or role labels, that mappings read with
+// lookup('name', key) instead of joining it into every query.
+type LookupConfig struct {
+	// Query is the SQL statement that reads the whole table. It does not support pagination tokens.
+	Query string `yaml:"query" json:"query"`
+
+	// KeyColumn is the column that lookup keys are matched against. Keys are compared as strings, so an integer
+	// key matches a text column holding the same number. If several rows share a key the last one wins.
+	KeyColumn string `yaml:"key_column" json:"key_column"`
+
+	// Connection names the connection from connect.connections to run the query on. Defaults to the default connection.
+	Connection string `yaml:"connection,omitempty" json:"connection,omitempty"`
+
+	// Timeout bounds how long loading the table may take (e.g. "30s").
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Columns overrides how the values of the named columns are converted before CEL sees them: raw, string, int,
+	// number, double, bool, timestamp, bytes or uuid.
+	Columns map[string]string `yaml:"columns,omitempty" json:"columns,omitempty"`
+
+	// Refresh is when the table is loaded: "sync" (default) reloads it at the start of every sync, and "once" loads
+	// it before the first sync and keeps it for the life of the connector.
+	Refresh string `yaml:"refresh,omitempty" json:"refresh,omitempty"`
+}
+
+// EventsConfig defines the query that reads events and how each row maps to events.
+type EventsConfig struct {
+	// Query is the SQL statement used to read events. It may use the ?<Since> token, bound to the earliest event
+	// time requested, and the ?<Cursor> and ?<Limit> tokens to page through the events in cursor column order.
+	Query string `yaml:"query" json:"query"`
+
+	// Connection names the connection from connect.connections to run the query on. Defaults to the default connection.
+	Connection string `yaml:"connection,omitempty" json:"connection,omitempty"`
+
+	// Timeout bounds how long the query may run for a single page, including processing its rows (e.g. "30s").
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Columns overrides how the values of the named columns are converted before CEL sees them: raw, string, int,
+	// number, double, bool, timestamp, bytes or uuid.
+	Columns map[string]string `yaml:"columns,omitempty" json:"columns,omitempty"`
+
+	// CursorColumn is the column that orders the events and is used as the stream cursor, usually an increasing ID.
+	CursorColumn string `yaml:"cursor_column" json:"cursor_column"`
+
+	// CursorType is the type of the cursor column: "integer" (default), "string" or "timestamp". Before the first
+	// event has been read, ?<Cursor> is bound to the zero value of the type.
+	CursorType string `yaml:"cursor_type,omitempty" json:"cursor_type,omitempty"`
+
+	// Map contains mappings to interpret each row of the query result as an event.
+	Map []*EventMapping `yaml:"map" json:"map"`
+}
+
+// EventMapping maps a row of the events query to an event.
+type EventMapping struct {
+	// SkipIf provides a CEL expression to ignore this row mapping if the condition evaluates to true.
+	SkipIf string `yaml:"skip_if,omitempty" json:"skip_if,omitempty"`
+
+	// Type is the kind of event: "usage", "grant" or "revoke".
+	Type string `yaml:"type" json:"type"`
+
+	// ID is a CEL expression for the event ID. Defaults to the cursor column value.
+	ID string `yaml:"id,omitempty" json:"id,omitempty"`
+
+	// OccurredAt is a CEL expression for when the event happened, as a timestamp or a timestamp string.
+	OccurredAt string `yaml:"occurred_at" json:"occurred_at"`
+
+	// Target is the resource that was used, for usage events.
+	Target *EventResource `yaml:"target,omitempty" json:"target,omitempty"`
+
+	// Actor is the resource that used the target, for usage events.
+	Actor *EventResource `yaml:"actor,omitempty" json:"actor,omitempty"`
+
+	// Resource is the resource that owns the entitlement, for grant and revoke events.
+	Resource *EventResource `yaml:"resource,omitempty" json:"resource,omitempty"`
+
+	// Entitlement is a CEL expression for the entitlement ID on Resource, for grant and revoke events.
+	Entitlement string `yaml:"entitlement_id,omitempty" json:"entitlement_id,omitempty"`
+
+	// Principal is the resource the entitlement was granted to or revoked from, for grant and revoke events.
+	Principal *EventResource `yaml:"principal,omitempty" json:"principal,omitempty"`
+}
+
+// EventResource identifies a resource referenced by an event.
+type EventResource struct {
+	// ResourceType is the resource type ID of the resource.
+	ResourceType string `yaml:"resource_type" json:"resource_type"`
+
+	// ID is a CEL expression for the resource ID.
+	ID string `yaml:"id" json:"id"`
+
+	// DisplayName is an optional CEL expression for the resource display name.
+	DisplayName string `yaml:"display_name,omitempty" json:"display_name,omitempty"`
+}
+
 // Parse converts YAML-encoded configuration data into a Config struct.
-func Parse(data []byte) (*Config, error) {
-	config := &Config{}
-	err := yaml.Unmarshal(data, config)
+// Includes are resolved relative to the working directory. If the configuration selects a preset,
+// it is merged over the preset, and fragment references are expanded before decoding.
+func Parse(data []byte, opts ...Option) (*Config, error) {
+	o, err := newLoadOptions(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return config, nil
+	return parse(data, o)
 }
 
 // LoadConfigFromFile reads a YAML configuration file from the given path and parses its content into a Config struct.
-func LoadConfigFromFile(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+// Includes are resolved relative to the directory of the file.
+func LoadConfigFromFile(path string, opts ...Option) (*Config, error) {
+	o, err := newLoadOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	o.path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(o.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(data, o)
+}
+
+func parse(data []byte, o *loadOptions) (*Config, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
 	if err != nil {
 		return nil, err
 	}
 
 	config := &Config{}
-	err = yaml.Unmarshal(data, config)
+	if len(doc.Content) == 0 {
+		return config, nil
+	}
+
+	source, dir, stack := inlineSource, "", []string(nil)
+	if o.path != "" {
+		source, dir, stack = o.path, filepath.Dir(o.path), []string{o.path}
+	}
+
+	sources := make(nodeSources)
+	root, _, err := loadIncludes(doc.Content[0], source, dir, stack, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err = applyPreset(root, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err = expandFragments(root, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	err = resolveSecrets(root, o)
+	if err != nil {
+		return nil, err
+	}
+
+	err = root.Decode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	config.lines = make(map[string]sourceLine)
+	nodeLines(root, "", sources, config.lines)
+	config.identities = o.identities
+
+	err = config.validate()
 	if err != nil {
 		return nil, err
 	}
 
 	return config, nil
 }
+
+// validate checks the settings that can be checked without a database, so that mistakes fail the config load
+// rather than a sync.
+func (c *Config) validate() error {
+	var errs []error
+
+	if c.UsesIncrementalSync() && !c.ExperimentalIncrementalSync {
+		errs = append(errs, errors.New("incremental queries require experimental_incremental_sync: true"))
+	}
+
+	for _, name := range append([]string{""}, sortedKeys(c.Connect.Connections)...) {
+		d, path := &c.Connect, "connect"
+		if name != "" {
+			d, path = c.Connect.Connections[name], joinPath("connect", "connections", name)
+		}
+		if d == nil || d.Snapshot == nil || !d.Snapshot.Enabled {
+			continue
+		}
+		var engine database.DbEngine
+		if conn, err := d.ReadConnection(); err == nil {
+			engine = database.EngineFromDSN(conn.DSN)
+		}
+		err := database.ValidateIsolationLevel(engine, d.Snapshot.IsolationLevel)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.location(joinPath(path, "snapshot", "isolation_level")), err))
+		}
+	}
+
+	for _, q := range c.queries() {
+		errs = append(errs, c.validateQueryTokens(q)...)
+		for _, name := range sortedKeys(q.columns) {
+			err := database.ValidateColumnHint(q.columns[name])
+			if err != nil {
+				// The columns of a query are a sibling of the query.
+				path := joinPath(strings.TrimSuffix(q.path, ".query"), "columns", name)
+				errs = append(errs, fmt.Errorf("%s: %w", c.location(path), err))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(c.Lookups) {
+		l := c.Lookups[name]
+		if l == nil {
+			continue
+		}
+		switch l.Refresh {
+		case "", lookupRefreshSync, lookupRefreshOnce:
+		default:
+			errs = append(errs, fmt.Errorf("%s: invalid refresh %q, expected %s or %s",
+				c.location(joinPath("lookups", name, "refresh")), l.Refresh, lookupRefreshSync, lookupRefreshOnce))
+		}
+	}
+
+	for _, name := range sortedKeys(c.ResourceTypes) {
+		rt := c.ResourceTypes[name]
+		if rt.List == nil || rt.List.Map == nil || rt.List.Map.Traits == nil || rt.List.Map.Traits.User == nil {
+			continue
+		}
+		u := rt.List.Map.Traits.User
+		path := joinPath("resource_types", name, "list", "map", "traits", "user")
+		for _, err := range validateTraitValues(u.StatusValues, userStatuses) {
+			errs = append(errs, fmt.Errorf("%s: %w", c.location(joinPath(path, "status_values")), err))
+		}
+		for _, err := range validateTraitValues(u.AccountTypeValues, accountTypes) {
+			errs = append(errs, fmt.Errorf("%s: %w", c.location(joinPath(path, "account_type_values")), err))
+		}
+	}
+
+	for _, p := range c.provisioning() {
+		for _, k := range sortedKeys(p.config.Keywords) {
+			for i, kw := range p.config.Keywords[k] {
+				err := database.ValidateKeyword(kw)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", c.location(indexPath(joinPath(p.path, "keywords", k), i)), err))
+				}
+			}
+		}
+
+		engine := c.writeEngine(p.config.Connection)
+		for _, op := range []struct {
+			name    string
+			queries *EntitlementProvisioningQueries
+		}{{"grant", p.config.Grant}, {"revoke", p.config.Revoke}} {
+			if op.queries == nil {
+				continue
+			}
+			err := database.ValidateIsolationLevel(engine, op.queries.IsolationLevel)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", c.location(joinPath(p.path, op.name, "isolation_level")), err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// configQuery is a SQL statement in the config and the YAML path it was read from. keywords is the keyword
+// allow-list of provisioning queries.
+type configQuery struct {
+	path         string
+	query        string
+	columns      map[string]string
+	provisioning bool
+	keywords     map[string][]string
+}
+
+// configProvisioning is the provisioning of an entitlement and the YAML path it was read from.
+type configProvisioning struct {
+	path   string
+	config *EntitlementProvisioning
+}
+
+// provisioning returns the entitlement provisioning configs in a stable order.
+func (c *Config) provisioning() []configProvisioning {
+	var ret []configProvisioning
+	add := func(path string, m *EntitlementMapping) {
+		if m != nil && m.Provisioning != nil {
+			ret = append(ret, configProvisioning{path: joinPath(path, "provisioning"), config: m.Provisioning})
+		}
+	}
+
+	for _, name := range sortedKeys(c.ResourceTypes) {
+		rt := c.ResourceTypes[name]
+		path := joinPath("resource_types", name)
+
+		if rt.Entitlements != nil {
+			for i, m := range rt.Entitlements.Map {
+				add(indexPath(joinPath(path, "entitlements", "map"), i), m)
+			}
+		}
+		for i, m := range rt.StaticEntitlements {
+			add(indexPath(joinPath(path, "static_entitlements"), i), m)
+		}
+	}
+
+	return ret
+}
+
+// queries returns the SQL statements of the config in a stable order.
+func (c *Config) queries() []configQuery {
+	var ret []configQuery
+	add := func(path string, query string, columns map[string]string) {
+		if query != "" {
+			ret = append(ret, configQuery{path: path, query: query, columns: columns})
+		}
+	}
+
+	for _, name := range sortedKeys(c.ResourceTypes) {
+		rt := c.ResourceTypes[name]
+		path := joinPath("resource_types", name)
+
+		if rt.List != nil {
+			add(joinPath(path, "list", "query"), rt.List.Query, rt.List.Columns)
+		}
+		if rt.Entitlements != nil {
+			add(joinPath(path, "entitlements", "query"), rt.Entitlements.Query, rt.Entitlements.Columns)
+		}
+		for i, g := range rt.Grants {
+			if g != nil {
+				add(joinPath(indexPath(joinPath(path, "grants"), i), "query"), g.Query, g.Columns)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(c.Lookups) {
+		if l := c.Lookups[name]; l != nil {
+			add(joinPath("lookups", name, "query"), l.Query, l.Columns)
+		}
+	}
+
+	if c.Events != nil {
+		add("events.query", c.Events.Query, c.Events.Columns)
+	}
+
+	for _, p := range c.provisioning() {
+		for _, op := range []struct {
+			key     string
+			queries *EntitlementProvisioningQueries
+		}{{"grant", p.config.Grant}, {"revoke", p.config.Revoke}} {
+			if op.queries == nil {
+				continue
+			}
+			for i, step := range op.queries.Queries {
+				if step == nil || step.Query == "" {
+					continue
+				}
+				ret = append(ret, configQuery{
+					path:         indexPath(joinPath(p.path, op.key, "queries"), i),
+					query:        step.Query,
+					provisioning: true,
+					keywords:     p.config.Keywords,
+				})
+			}
+		}
+	}
+
+	return ret
+}
+
+// validateQueryTokens checks the inline options of the tokens in q: unquoted must be allowed by the config, and
+// keyword is only allowed in provisioning queries, on vars with an allow-list of valid keywords.
+func (c *Config) validateQueryTokens(q configQuery) []error {
+	var errs []error
+	for _, token := range queryOptRegex.FindAllString(q.query, -1) {
+		opts, err := parseToken(token)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: in token %s: %w", c.location(q.path), token, err))
+			continue
+		}
+
+		switch {
+		case opts.Unquoted && !c.AllowUnquoted:
+			errs = append(errs, fmt.Errorf("%s: token %s uses the unquoted option, which requires allow_unquoted: true",
+				c.location(q.path), token))
+		case opts.Keyword && !q.provisioning:
+			errs = append(errs, fmt.Errorf("%s: token %s: the keyword option is only supported in provisioning queries",
+				c.location(q.path), token))
+		case opts.Keyword && len(q.keywords[opts.Key]) == 0:
+			errs = append(errs, fmt.Errorf("%s: token %s: the keyword option requires a keywords allow-list for %s",
+				c.location(q.path), token, opts.Key))
+		}
+	}
+	return errs
+}
@@ -1,12 +1,17 @@
 package bsql
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
 
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/stretchr/testify/require"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
 )
 
 func loadExampleConfig(t *testing.T, exampleName string) string {
@@ -110,3 +115,450 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestDatabaseConfig_ReadWriteConnections(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantRead  string
+		wantWrite string
+		wantErr   bool
+	}{
+		{
+			name: "single connection",
+			input: `
+connect:
+  dsn: "mysql://primary/db"
+`,
+			wantRead:  "mysql://primary/db",
+			wantWrite: "mysql://primary/db",
+		},
+		{
+			name: "read replica with default write",
+			input: `
+connect:
+  dsn: "mysql://primary/db"
+  read:
+    dsn: "mysql://replica/db"
+`,
+			wantRead:  "mysql://replica/db",
+			wantWrite: "mysql://primary/db",
+		},
+		{
+			name: "read and write without default",
+			input: `
+connect:
+  read:
+    dsn: "mysql://replica/db"
+  write:
+    dsn: "mysql://primary/db"
+`,
+			wantRead:  "mysql://replica/db",
+			wantWrite: "mysql://primary/db",
+		},
+		{
+			name: "read without default or write",
+			input: `
+connect:
+  read:
+    dsn: "mysql://replica/db"
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte(tt.input))
+			require.NoError(t, err)
+
+			read, err := c.Connect.ReadConnection()
+			if tt.wantErr {
+				if err == nil {
+					_, err = c.Connect.WriteConnection()
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRead, read.DSN)
+
+			write, err := c.Connect.WriteConnection()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantWrite, write.DSN)
+		})
+	}
+}
+
+func TestConfig_UsedConnections(t *testing.T) {
+	c, err := Parse([]byte(`
+connect:
+  dsn: "mysql://identity/db"
+  connections:
+    apps:
+      dsn: "sqlserver://apps/db"
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT id FROM users
+  permission:
+    name: Permission
+    list:
+      connection: apps
+      query: SELECT id FROM permissions
+    static_entitlements:
+    - id: assigned
+      provisioning:
+        connection: apps
+    grants:
+    - connection: apps
+      query: SELECT user_id FROM user_permissions
+`))
+	require.NoError(t, err)
+	require.Equal(t, "sqlserver://apps/db", c.Connect.Connections["apps"].DSN)
+
+	syncConns, provisioningConns := c.UsedConnections()
+	require.Equal(t, map[string]bool{"": true, "apps": true}, syncConns)
+	require.Equal(t, map[string]bool{"apps": true}, provisioningConns)
+}
+
+func TestParse_ProvisioningSteps(t *testing.T) {
+	c, err := Parse([]byte(`
+resource_types:
+  role:
+    name: Role
+    static_entitlements:
+    - id: member
+      provisioning:
+        vars:
+          principal_id: principal.ID
+        grant:
+          queries:
+          - INSERT INTO audit (user_id) VALUES (?<principal_id>)
+          - procedure:
+              name: dbo.sp_grant_role
+              params:
+              - name: user_id
+                var: principal_id
+              - name: result_code
+                direction: out
+                type: int
+              success: ".return_status == 0 && .result_code == 0"
+`))
+	require.NoError(t, err)
+
+	steps := c.ResourceTypes["role"].StaticEntitlements[0].Provisioning.Grant.Queries
+	require.Len(t, steps, 2)
+	require.Equal(t, "INSERT INTO audit (user_id) VALUES (?<principal_id>)", steps[0].Query)
+	require.Nil(t, steps[0].Procedure)
+
+	require.NotNil(t, steps[1].Procedure)
+	require.Equal(t, "dbo.sp_grant_role", steps[1].Procedure.Name)
+	require.Len(t, steps[1].Procedure.Params, 2)
+	require.Equal(t, "principal_id", steps[1].Procedure.Params[0].Var)
+	require.Equal(t, "out", steps[1].Procedure.Params[1].Direction)
+
+	_, err = Parse([]byte(`
+resource_types:
+  role:
+    name: Role
+    static_entitlements:
+    - id: member
+      provisioning:
+        grant:
+          queries:
+          - query: SELECT 1
+            procedure:
+              name: sp_grant_role
+`))
+	require.Error(t, err)
+}
+
+func TestProvisioningStep_UnmarshalJSON(t *testing.T) {
+	var q EntitlementProvisioningQueries
+	err := json.Unmarshal([]byte(`{"queries": ["DELETE FROM grants", {"procedure": {"name": "sp_revoke_role"}}]}`), &q)
+	require.NoError(t, err)
+	require.Len(t, q.Queries, 2)
+	require.Equal(t, "DELETE FROM grants", q.Queries[0].Query)
+	require.Equal(t, "sp_revoke_role", q.Queries[1].Procedure.Name)
+
+	err = json.Unmarshal([]byte(`{"queries": [{"query": "SELECT 1", "procedure": {"name": "sp_revoke_role"}}]}`), &q)
+	require.Error(t, err)
+}
+
+func TestParse_BitmaskExample(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := Parse([]byte(loadExampleConfig(t, "bitmask")))
+	require.NoError(t, err)
+
+	workspace := c.ResourceTypes["workspace"]
+	require.Len(t, workspace.StaticEntitlements, 4)
+	require.Len(t, workspace.Grants, 1)
+	require.Len(t, workspace.Grants[0].Map, 4)
+
+	env, err := bcel.NewEnv(ctx)
+	require.NoError(t, err)
+
+	resource := &v2.Resource{Id: &v2.ResourceId{ResourceType: "workspace", Resource: "7"}}
+	inputs := env.SyncInputsWithResource(map[string]any{
+		"workspace_id": int64(7),
+		"user_id":      int64(1),
+		"perm_flags":   int64(13),
+	}, resource)
+
+	var granted []string
+	for _, m := range workspace.Grants[0].Map {
+		skip, err := env.EvaluateBool(ctx, m.SkipIf, inputs)
+		require.NoError(t, err)
+		if !skip {
+			granted = append(granted, m.Entitlement)
+		}
+	}
+	require.Equal(t, []string{"read", "approve", "admin"}, granted)
+}
+
+func TestParse_Functions(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := Parse([]byte(`
+functions:
+  wpRole:
+    params: [role_name]
+    expr: "phpDeserializeStringArray(string(role_name))[0]"
+  fullName:
+    params:
+    - name: first
+      type: string
+    - last
+    expr: "first + ' ' + string(last)"
+`))
+	require.NoError(t, err)
+
+	decls := c.FunctionDecls()
+	require.Equal(t, []bcel.FunctionDecl{
+		{
+			Name:   "fullName",
+			Params: []bcel.FunctionParam{{Name: "first", Type: "string"}, {Name: "last"}},
+			Expr:   "first + ' ' + string(last)",
+		},
+		{
+			Name:   "wpRole",
+			Params: []bcel.FunctionParam{{Name: "role_name"}},
+			Expr:   "phpDeserializeStringArray(string(role_name))[0]",
+		},
+	}, decls)
+
+	env, err := bcel.NewEnv(ctx, bcel.WithFunctions(decls...))
+	require.NoError(t, err)
+
+	out, err := env.EvaluateString(ctx, "wpRole(.role_name)", env.SyncInputs(map[string]any{
+		"role_name": []byte(`a:1:{s:13:"administrator";b:1;}`),
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "administrator", out)
+}
+
+func TestParse_Columns(t *testing.T) {
+	c, err := Parse([]byte(`
+lookups:
+  departments:
+    query: SELECT id, name FROM departments
+    key_column: id
+    columns:
+      id: string
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT id, is_admin FROM users
+      columns:
+        is_admin: bool
+      map:
+        id: .id
+        display_name: .id
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"is_admin": "bool"}, c.ResourceTypes["user"].List.Columns)
+	require.Equal(t, map[string]string{"id": "string"}, c.Lookups["departments"].Columns)
+
+	_, err = Parse([]byte(`
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT id, is_admin FROM users
+      columns:
+        is_admin: boolean
+      map:
+        id: .id
+        display_name: .id
+`))
+	require.ErrorContains(t, err, `resource_types.user.list.columns.is_admin (line 8): unknown column type "boolean"`)
+}
+
+func TestParse_QueryTokenOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "unquoted is disabled",
+			config: `
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT * FROM ?<table|unquoted>
+`,
+			wantErr: "resource_types.user.list.query (line 6): token ?<table|unquoted> uses the unquoted option",
+		},
+		{
+			name: "unquoted is allowed",
+			config: `
+allow_unquoted: true
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT * FROM ?<table|unquoted>
+`,
+		},
+		{
+			name: "keyword in a sync query",
+			config: `
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT * FROM users ORDER BY ?<order|keyword>
+`,
+			wantErr: "only supported in provisioning queries",
+		},
+		{
+			name: "keyword without an allow-list",
+			config: `
+resource_types:
+  privilege:
+    name: Privilege
+    static_entitlements:
+    - id: assigned
+      provisioning:
+        vars:
+          privilege: resource.ID
+        grant:
+          queries:
+          - GRANT ?<privilege|keyword> TO ?<principal|ident>
+`,
+			wantErr: "requires a keywords allow-list for privilege",
+		},
+		{
+			name: "invalid allow-list entry",
+			config: `
+resource_types:
+  privilege:
+    name: Privilege
+    static_entitlements:
+    - id: assigned
+      provisioning:
+        vars:
+          privilege: resource.ID
+        keywords:
+          privilege:
+          - CREATE SESSION
+          - DBA; DROP USER scott
+        grant:
+          queries:
+          - GRANT ?<privilege|keyword> TO ?<principal|ident>
+`,
+			wantErr: "keywords.privilege[1] (line 13)",
+		},
+		{
+			name: "keyword with an allow-list",
+			config: `
+resource_types:
+  privilege:
+    name: Privilege
+    static_entitlements:
+    - id: assigned
+      provisioning:
+        vars:
+          privilege: resource.ID
+        keywords:
+          privilege: [CREATE SESSION]
+        grant:
+          queries:
+          - GRANT ?<privilege|keyword> TO ?<principal|ident>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.config))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParse_IsolationLevel(t *testing.T) {
+	config := func(connect string) string {
+		return connect + `
+resource_types:
+  role:
+    name: Role
+    static_entitlements:
+    - id: member
+      provisioning:
+        grant:
+          isolation_level: repeatable_read
+          queries:
+          - INSERT INTO role_members (role_id, user_id) VALUES (?<resource_id>, ?<principal_id>)
+`
+	}
+
+	_, err := Parse([]byte(config(`
+connect:
+  dsn: mysql://db.example.com/app
+`)))
+	require.NoError(t, err)
+
+	_, err = Parse([]byte(config(`
+connect:
+  dsn: oracle://db.example.com/app
+`)))
+	require.ErrorContains(t, err, "resource_types.role.static_entitlements[0].provisioning.grant.isolation_level (line 12): "+
+		"oracle does not support isolation level Repeatable Read")
+
+	// The engine of a DSN that comes from a variable is not known until connecting.
+	_, err = Parse([]byte(config(`
+connect:
+  dsn: ${DSN}
+`)))
+	require.NoError(t, err)
+}
+
+func TestParse_SnapshotIsolationLevel(t *testing.T) {
+	_, err := Parse([]byte(`
+connect:
+  dsn: mysql://db.example.com/app
+  snapshot:
+    enabled: true
+    isolation_level: serializable
+  connections:
+    hr:
+      dsn: oracle://hr.example.com/hr
+      snapshot:
+        enabled: true
+        isolation_level: repeatable_read
+resource_types: {}
+`))
+	require.ErrorContains(t, err, "connect.connections.hr.snapshot.isolation_level (line 12): "+
+		"oracle does not support isolation level Repeatable Read")
+	require.NotContains(t, err.Error(), "connect.snapshot")
+}
@@ -79,7 +79,18 @@ func (s *SQLSyncer) dynamicEntitlements(ctx context.Context, resource *v2.Resour
 
 	var ret []*v2.Entitlement
 
-	npt, err := s.runQuery(ctx, pToken, s.config.Entitlements.Query, s.config.Entitlements.Pagination, func(ctx context.Context, rowMap map[string]any) (bool, error) {
+	sc, err := s.forConnection(s.config.Entitlements.Connection)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	npt, err := sc.runQuery(ctx, pToken, syncQuery{
+		Query:      s.config.Entitlements.Query,
+		Pagination: s.config.Entitlements.Pagination,
+		Timeout:    s.config.Entitlements.Timeout,
+		Vars:       map[string]any{resourceIDKey: resource.GetId().GetResource()},
+		Columns:    s.config.Entitlements.Columns,
+	}, func(ctx context.Context, rowMap map[string]any) (bool, error) {
 		for _, mapping := range s.config.Entitlements.Map {
 			r, ok, err := s.mapEntitlement(ctx, resource, mapping, rowMap)
 			if err != nil {
@@ -0,0 +1,272 @@
+package bsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
+	"github.com/conductorone/baton-sql/pkg/bcel/functions"
+)
+
+const (
+	usageEventType  = "usage"
+	grantEventType  = "grant"
+	revokeEventType = "revoke"
+)
+
+// EventFeed reads events from the events query.
+type EventFeed struct {
+	syncer *SQLSyncer
+	config *EventsConfig
+}
+
+// GetEventFeed returns the event feed for the config, or nil if no events are configured.
+func (c Config) GetEventFeed(conns DBConnections, celEnv *bcel.Env) *EventFeed {
+	if c.Events == nil {
+		return nil
+	}
+
+	return &EventFeed{
+		syncer: &SQLSyncer{
+			connections: conns,
+			env:         celEnv,
+			fullConfig:  c,
+			// Events are polled between syncs and must see new rows, not the snapshot of the last sync.
+			noSnapshot: true,
+		},
+		config: c.Events,
+	}
+}
+
+// ListEvents returns the events after the stream cursor. On the first page the cursor is empty and ?<Since> is bound
+// to earliestEvent. Once the events are exhausted the cursor stays on the last event, so the next call resumes there.
+func (f *EventFeed) ListEvents(
+	ctx context.Context,
+	earliestEvent *timestamppb.Timestamp,
+	pToken *pagination.StreamToken,
+) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	if f.config.CursorColumn == "" {
+		return nil, nil, nil, errors.New("events query requires a cursor_column")
+	}
+
+	sc, err := f.syncer.forConnection(f.config.Connection)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	since := time.Unix(0, 0).UTC()
+	if earliestEvent != nil {
+		since = earliestEvent.AsTime()
+	}
+
+	cursorType := f.config.CursorType
+	if cursorType == "" {
+		cursorType = cursorTypeInteger
+	}
+
+	var ret []*v2.Event
+	cursor := pToken.Cursor
+
+	npt, err := sc.runQuery(ctx, &pagination.Token{
+		Size:  pToken.Size,
+		Token: pToken.Cursor,
+	}, syncQuery{
+		Query: f.config.Query,
+		Pagination: &Pagination{
+			Strategy:   cursorKey,
+			PrimaryKey: f.config.CursorColumn,
+			CursorType: cursorType,
+		},
+		Timeout: f.config.Timeout,
+		Vars:    map[string]any{sinceKey: since},
+		Columns: f.config.Columns,
+	}, func(ctx context.Context, rowMap map[string]any) (bool, error) {
+		v, ok := rowMap[f.config.CursorColumn]
+		if !ok {
+			return false, fmt.Errorf("cursor column %s not found in events query results", f.config.CursorColumn)
+		}
+		rowCursor, err := formatCursor(v)
+		if err != nil {
+			return false, err
+		}
+
+		for _, mapping := range f.config.Map {
+			event, ok, err := f.mapEvent(ctx, mapping, rowCursor, rowMap)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				ret = append(ret, event)
+			}
+		}
+
+		cursor = rowCursor
+		return true, nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return ret, &pagination.StreamState{
+		Cursor:  cursor,
+		HasMore: npt != "",
+	}, nil, nil
+}
+
+func (f *EventFeed) mapEvent(ctx context.Context, mapping *EventMapping, rowCursor string, rowMap map[string]any) (*v2.Event, bool, error) {
+	if mapping == nil {
+		return nil, false, errors.New("error: missing event mapping")
+	}
+
+	env := f.syncer.env
+	inputs := env.SyncInputs(rowMap)
+
+	if mapping.SkipIf != "" {
+		skip, err := env.EvaluateBool(ctx, mapping.SkipIf, inputs)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if skip {
+			return nil, false, nil
+		}
+	}
+
+	if mapping.OccurredAt == "" {
+		return nil, false, errors.New("error: missing occurred_at mapping for event")
+	}
+
+	ret := &v2.Event{
+		Id: rowCursor,
+	}
+
+	if mapping.ID != "" {
+		id, err := env.EvaluateString(ctx, mapping.ID, inputs)
+		if err != nil {
+			return nil, false, err
+		}
+		ret.Id = id
+	}
+
+	occurredAt, err := env.Evaluate(ctx, mapping.OccurredAt, inputs)
+	if err != nil {
+		return nil, false, err
+	}
+	t, err := toTime(occurredAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid occurred_at for event %s: %w", ret.Id, err)
+	}
+	ret.OccurredAt = timestamppb.New(t)
+
+	switch mapping.Type {
+	case usageEventType:
+		target, err := f.mapEventResource(ctx, mapping.Target, inputs)
+		if err != nil {
+			return nil, false, fmt.Errorf("usage event target: %w", err)
+		}
+		actor, err := f.mapEventResource(ctx, mapping.Actor, inputs)
+		if err != nil {
+			return nil, false, fmt.Errorf("usage event actor: %w", err)
+		}
+		ret.Event = &v2.Event_UsageEvent{
+			UsageEvent: &v2.UsageEvent{
+				TargetResource: target,
+				ActorResource:  actor,
+			},
+		}
+
+	case grantEventType, revokeEventType:
+		resource, err := f.mapEventResource(ctx, mapping.Resource, inputs)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s event resource: %w", mapping.Type, err)
+		}
+		principal, err := f.mapEventResource(ctx, mapping.Principal, inputs)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s event principal: %w", mapping.Type, err)
+		}
+		if mapping.Entitlement == "" {
+			return nil, false, fmt.Errorf("error: missing entitlement ID mapping for %s event", mapping.Type)
+		}
+		entitlementID, err := env.EvaluateString(ctx, mapping.Entitlement, inputs)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if mapping.Type == grantEventType {
+			ret.Event = &v2.Event_GrantEvent{
+				GrantEvent: &v2.GrantEvent{
+					Grant: sdkGrant.NewGrant(resource, entitlementID, principal),
+				},
+			}
+		} else {
+			ret.Event = &v2.Event_RevokeEvent{
+				RevokeEvent: &v2.RevokeEvent{
+					Entitlement: &v2.Entitlement{
+						Id:       sdkEntitlement.NewEntitlementID(resource, entitlementID),
+						Resource: resource,
+					},
+					Principal: principal,
+				},
+			}
+		}
+
+	default:
+		return nil, false, fmt.Errorf("unknown event type %q, expected usage, grant or revoke", mapping.Type)
+	}
+
+	return ret, true, nil
+}
+
+func (f *EventFeed) mapEventResource(ctx context.Context, mapping *EventResource, inputs map[string]any) (*v2.Resource, error) {
+	if mapping == nil {
+		return nil, errors.New("missing resource mapping")
+	}
+
+	if mapping.ResourceType == "" {
+		return nil, errors.New("missing resource type")
+	}
+
+	if mapping.ID == "" {
+		return nil, errors.New("missing resource ID mapping")
+	}
+
+	env := f.syncer.env
+
+	id, err := env.EvaluateString(ctx, mapping.ID, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: mapping.ResourceType,
+			Resource:     id,
+		},
+	}
+
+	if mapping.DisplayName != "" {
+		ret.DisplayName, err = env.EvaluateString(ctx, mapping.DisplayName, inputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// toTime converts a timestamp, a string in one of the supported timestamp layouts, or Unix seconds to a time.
+func toTime(v any) (time.Time, error) {
+	if t, ok := v.(*timestamppb.Timestamp); ok {
+		return t.AsTime(), nil
+	}
+	return functions.ToTimestamp(v)
+}
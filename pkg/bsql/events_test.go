@@ -0,0 +1,133 @@
+package bsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
+	"github.com/conductorone/baton-sql/pkg/database"
+)
+
+func TestEventFeed_mapEvent(t *testing.T) {
+	ctx := context.Background()
+	env, err := bcel.NewEnv(ctx)
+	require.NoError(t, err)
+
+	f := Config{Events: &EventsConfig{CursorColumn: "id"}}.GetEventFeed(nil, env)
+	require.NotNil(t, f)
+
+	occurredAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	rowMap := map[string]any{
+		"id":         int64(12),
+		"action":     "role_added",
+		"actor_id":   "42",
+		"role_id":    "editor",
+		"created_at": occurredAt,
+	}
+
+	t.Run("usage", func(t *testing.T) {
+		event, ok, err := f.mapEvent(ctx, &EventMapping{
+			Type:       usageEventType,
+			OccurredAt: ".created_at",
+			Target:     &EventResource{ResourceType: "app", ID: "'wordpress'"},
+			Actor:      &EventResource{ResourceType: "user", ID: ".actor_id"},
+		}, "12", rowMap)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "12", event.GetId())
+		require.Equal(t, occurredAt, event.GetOccurredAt().AsTime())
+		require.Equal(t, "wordpress", event.GetUsageEvent().GetTargetResource().GetId().GetResource())
+		require.Equal(t, "42", event.GetUsageEvent().GetActorResource().GetId().GetResource())
+	})
+
+	t.Run("grant", func(t *testing.T) {
+		event, ok, err := f.mapEvent(ctx, &EventMapping{
+			Type:        grantEventType,
+			ID:          "'audit-' + string(.id)",
+			OccurredAt:  "'2024-03-01 09:30:00'",
+			Resource:    &EventResource{ResourceType: "role", ID: ".role_id"},
+			Entitlement: "'member'",
+			Principal:   &EventResource{ResourceType: "user", ID: ".actor_id"},
+		}, "12", rowMap)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "audit-12", event.GetId())
+		require.Equal(t, occurredAt, event.GetOccurredAt().AsTime())
+		grant := event.GetGrantEvent().GetGrant()
+		require.Equal(t, "role:editor:member", grant.GetEntitlement().GetId())
+		require.Equal(t, &v2.ResourceId{ResourceType: "user", Resource: "42"}, grant.GetPrincipal().GetId())
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		event, ok, err := f.mapEvent(ctx, &EventMapping{
+			Type:        revokeEventType,
+			OccurredAt:  ".created_at",
+			Resource:    &EventResource{ResourceType: "role", ID: ".role_id"},
+			Entitlement: "'member'",
+			Principal:   &EventResource{ResourceType: "user", ID: ".actor_id"},
+		}, "12", rowMap)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "role:editor:member", event.GetRevokeEvent().GetEntitlement().GetId())
+		require.Equal(t, "42", event.GetRevokeEvent().GetPrincipal().GetId().GetResource())
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		_, ok, err := f.mapEvent(ctx, &EventMapping{
+			Type:       usageEventType,
+			SkipIf:     ".action == 'role_added'",
+			OccurredAt: ".created_at",
+		}, "12", rowMap)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, _, err := f.mapEvent(ctx, &EventMapping{
+			Type:       "resource_change",
+			OccurredAt: ".created_at",
+		}, "12", rowMap)
+		require.Error(t, err)
+	})
+}
+
+func TestEventFeed_ListEvents_cursor(t *testing.T) {
+	ctx := context.Background()
+	env, err := bcel.NewEnv(ctx)
+	require.NoError(t, err)
+
+	f, db := newFakeDB()
+	defer db.Close()
+	conns := DBConnections{"": {DB: db, WriteDB: db, Engine: database.Oracle}}
+
+	var cursors []any
+	f.query = func(query string, args []driver.NamedValue) (*fakeRows, error) {
+		cursors = append(cursors, args[1].Value)
+		if len(cursors) > 1 {
+			return &fakeRows{columns: []string{"id"}}, nil
+		}
+		return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(7)}}}, nil
+	}
+
+	feed := Config{Events: &EventsConfig{
+		Query:        "SELECT id FROM audit_log WHERE created_at >= ?<Since> AND id > ?<Cursor>",
+		CursorColumn: "id",
+	}}.GetEventFeed(conns, env)
+
+	_, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10})
+	require.NoError(t, err)
+	require.Equal(t, "7", state.Cursor)
+
+	_, state, _, err = feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10, Cursor: state.Cursor})
+	require.NoError(t, err)
+	require.Equal(t, "7", state.Cursor)
+
+	// The first poll binds a typed zero rather than '', which Oracle reads as NULL.
+	require.Equal(t, []any{int64(0), int64(7)}, cursors)
+}
@@ -0,0 +1,145 @@
+package bsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// fakeDB is an in-memory database/sql driver that records the statements it runs. It lets tests inject errors
+// and results without a database server.
+type fakeDB struct {
+	mu  sync.Mutex
+	log []string
+
+	// exec, if set, decides the result of every statement run with ExecContext. n counts the statements, from 1.
+	exec func(n int, query string, args []driver.NamedValue) error
+
+	// query, if set, returns the rows of every query.
+	query func(query string, args []driver.NamedValue) (*fakeRows, error)
+
+	execs int
+}
+
+func newFakeDB() (*fakeDB, *sql.DB) {
+	f := &fakeDB{}
+	return f, sql.OpenDB(f)
+}
+
+// statements returns what ran so far: statements, queries, "BEGIN", "COMMIT" and "ROLLBACK".
+func (f *fakeDB) statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.log...)
+}
+
+func (f *fakeDB) record(s string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = append(f.log, s)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{f}
+}
+
+type fakeDriver struct {
+	db *fakeDB
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{db: d.db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.record("COMMIT")
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.record("ROLLBACK")
+	return nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query)
+
+	c.db.mu.Lock()
+	c.db.execs++
+	n := c.db.execs
+	c.db.mu.Unlock()
+
+	if c.db.exec != nil {
+		err := c.db.exec(n, query, args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query)
+
+	if c.db.query == nil {
+		return &fakeRows{}, nil
+	}
+	rows, err := c.db.query(query, args)
+	if err != nil {
+		return nil, err
+	}
+	ret := *rows
+	return &ret, nil
+}
+
+// fakeRows is the result of a fake query.
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	pos     int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.pos])
+	r.pos++
+	return nil
+}
@@ -0,0 +1,147 @@
+package bsql
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	fragmentsKey = "fragments"
+	fragmentKey  = "fragment"
+)
+
+// expandFragments replaces every reference to a named fragment with the fragment's content.
+// Fragments are defined under the top level fragments key, and referenced with a `fragment: <name>` mapping in place
+// of a config value. Other keys next to the reference are merged over the fragment, so a resource type can reuse a
+// list query and only change its pagination, for example. In maps whose keys are names, such as profile or vars,
+// fragment is an ordinary key.
+func expandFragments(root *yaml.Node, sources nodeSources) (*yaml.Node, error) {
+	configType := reflect.TypeOf(Config{})
+
+	fragmentsNode := mappingValue(root, fragmentsKey)
+	if fragmentsNode == nil {
+		return expandNode(root, configType, nil, nil, sources)
+	}
+
+	if fragmentsNode.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: fragments must be a mapping of names to fragments", fragmentsNode.Line)
+	}
+
+	fragments := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(fragmentsNode.Content); i += 2 {
+		fragments[fragmentsNode.Content[i].Value] = fragmentsNode.Content[i+1]
+	}
+
+	return expandNode(withoutKey(root, fragmentsKey, sources), configType, fragments, nil, sources)
+}
+
+// expandNode returns a copy of node with its fragment references expanded. t is the type the node decodes into,
+// or nil if it is unknown. stack holds the fragments being expanded, to detect fragments that reference themselves.
+// Copies keep the source of the node they copy.
+func expandNode(node *yaml.Node, t reflect.Type, fragments map[string]*yaml.Node, stack []string, sources nodeSources) (*yaml.Node, error) {
+	t = derefType(t)
+
+	ret := sources.copy(node)
+	ret.Content = make([]*yaml.Node, 0, len(node.Content))
+	for i, child := range node.Content {
+		var childType reflect.Type
+		switch node.Kind {
+		case yaml.DocumentNode:
+			childType = t
+		case yaml.SequenceNode:
+			if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				childType = t.Elem()
+			}
+		case yaml.MappingNode:
+			if i%2 == 1 {
+				childType = valueType(t, node.Content[i-1].Value)
+			}
+		}
+
+		expanded, err := expandNode(child, childType, fragments, stack, sources)
+		if err != nil {
+			return nil, err
+		}
+		ret.Content = append(ret.Content, expanded)
+	}
+
+	if t != nil && t.Kind() == reflect.Map {
+		return ret, nil
+	}
+
+	ref := mappingValue(ret, fragmentKey)
+	if ref == nil {
+		return ret, nil
+	}
+
+	if ref.Kind != yaml.ScalarNode || ref.Value == "" {
+		return nil, fmt.Errorf("line %d: fragment must be the name of a fragment", ref.Line)
+	}
+
+	name := ref.Value
+	if slices.Contains(stack, name) {
+		return nil, fmt.Errorf("line %d: fragment cycle: %s", ref.Line, strings.Join(append(stack, name), " -> "))
+	}
+
+	fragment, ok := fragments[name]
+	if !ok {
+		return nil, fmt.Errorf("line %d: unknown fragment %q", ref.Line, name)
+	}
+
+	expanded, err := expandNode(fragment, t, fragments, append(stack, name), sources)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := withoutKey(ret, fragmentKey, sources)
+	if len(rest.Content) == 0 {
+		return expanded, nil
+	}
+
+	if expanded.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: fragment %q is not a mapping and cannot be combined with other keys", ref.Line, name)
+	}
+
+	return mergeNodes(expanded, rest, sources), nil
+}
+
+// valueType returns the type of the value stored under key in a value of type t, or nil if it is unknown.
+func valueType(t reflect.Type, key string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if strings.Contains(opts, "inline") {
+				if ft := valueType(derefType(f.Type), key); ft != nil {
+					return ft
+				}
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if name == key {
+				return f.Type
+			}
+		}
+	}
+	return nil
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,113 @@
+package bsql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Fragments(t *testing.T) {
+	c, err := Parse([]byte(`
+fragments:
+  users_query: "SELECT id, status FROM users"
+  user_traits:
+    status: ".status"
+  user_list:
+    query:
+      fragment: users_query
+    map:
+      id: ".id"
+      display_name: ".id"
+      traits:
+        user:
+          fragment: user_traits
+resource_types:
+  user:
+    name: "User"
+    list:
+      fragment: user_list
+  admin:
+    name: "Admin"
+    list:
+      fragment: user_list
+      query: "SELECT id, status FROM admins"
+`))
+	require.NoError(t, err)
+
+	user := c.ResourceTypes["user"]
+	require.Equal(t, "SELECT id, status FROM users", user.List.Query)
+	require.Equal(t, ".id", user.List.Map.Id)
+	require.Equal(t, ".status", user.List.Map.Traits.User.Status)
+
+	admin := c.ResourceTypes["admin"]
+	require.Equal(t, "SELECT id, status FROM admins", admin.List.Query)
+	require.Equal(t, ".id", admin.List.Map.Id)
+}
+
+func TestParse_FragmentErrors(t *testing.T) {
+	_, err := Parse([]byte(`
+resource_types:
+  user:
+    list:
+      fragment: missing
+`))
+	require.ErrorContains(t, err, `unknown fragment "missing"`)
+
+	_, err = Parse([]byte(`
+fragments:
+  a:
+    fragment: b
+  b:
+    fragment: a
+resource_types:
+  user:
+    list:
+      fragment: a
+`))
+	require.ErrorContains(t, err, "fragment cycle")
+
+	_, err = Parse([]byte(`
+fragments:
+  q: "SELECT 1"
+resource_types:
+  user:
+    list:
+      query:
+        fragment: q
+        timeout: 5s
+`))
+	require.ErrorContains(t, err, "is not a mapping")
+}
+
+func TestParse_FragmentKeyInMaps(t *testing.T) {
+	c, err := Parse([]byte(`
+fragments:
+  list:
+    query: "SELECT id, fragment FROM users"
+    map:
+      id: ".id"
+      traits:
+        user:
+          profile:
+            fragment: ".fragment"
+resource_types:
+  user:
+    name: "User"
+    list:
+      fragment: list
+    static_entitlements:
+    - id: member
+      provisioning:
+        vars:
+          fragment: principal.ID
+        grant:
+          queries:
+          - INSERT INTO members (id) VALUES (?<fragment>)
+`))
+	require.NoError(t, err)
+
+	user := c.ResourceTypes["user"]
+	// Profile and vars keys are names, so a key named fragment is not a reference.
+	require.Equal(t, map[string]string{"fragment": ".fragment"}, user.List.Map.Traits.User.Profile)
+	require.Equal(t, map[string]string{"fragment": "principal.ID"}, user.StaticEntitlements[0].Provisioning.Vars)
+}
@@ -45,7 +45,7 @@ func (s *SQLSyncer) Grants(ctx context.Context, resource *v2.Resource, pToken *p
 		grants, npt, err := s.listGrants(ctx, resource, &pagination.Token{
 			Size:  pToken.Size,
 			Token: current.Token,
-		}, s.config.Grants[grantIi])
+		}, s.config.Grants[grantIi], grantsScanKey(resource, grantIi))
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -68,14 +68,43 @@ func (s *SQLSyncer) Grants(ctx context.Context, resource *v2.Resource, pToken *p
 	return ret, nextPageToken, nil, nil
 }
 
-func (s *SQLSyncer) listGrants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token, grantConfig *GrantsQuery) ([]*v2.Grant, string, error) {
+func (s *SQLSyncer) listGrants(
+	ctx context.Context,
+	resource *v2.Resource,
+	pToken *pagination.Token,
+	grantConfig *GrantsQuery,
+	scanKey string,
+) ([]*v2.Grant, string, error) {
 	if grantConfig == nil {
 		return nil, "", errors.New("error: missing grants query")
 	}
 
 	var ret []*v2.Grant
 
-	npt, err := s.runQuery(ctx, pToken, grantConfig.Query, grantConfig.Pagination, func(ctx context.Context, rowMap map[string]any) (bool, error) {
+	sc, err := s.forConnection(grantConfig.Connection)
+	if err != nil {
+		return nil, "", err
+	}
+
+	q := syncQuery{
+		Query:      grantConfig.Query,
+		Pagination: grantConfig.Pagination,
+		Timeout:    grantConfig.Timeout,
+		Columns:    grantConfig.Columns,
+		Vars:       map[string]any{resourceIDKey: resource.GetId().GetResource()},
+	}
+
+	scan, err := s.startIncrementalScan(scanKey, grantConfig.Incremental, pToken.Token == "", &q)
+	if err != nil {
+		return nil, "", err
+	}
+
+	npt, err := sc.runQuery(ctx, pToken, q, func(ctx context.Context, rowMap map[string]any) (bool, error) {
+		err := scan.observe(rowMap)
+		if err != nil {
+			return false, err
+		}
+
 		for _, mapping := range grantConfig.Map {
 			g, ok, err := s.mapGrant(ctx, resource, mapping, rowMap)
 			if err != nil {
@@ -92,6 +121,8 @@ func (s *SQLSyncer) listGrants(ctx context.Context, resource *v2.Resource, pToke
 		return nil, "", err
 	}
 
+	scan.finish(npt)
+
 	return ret, npt, nil
 }
 
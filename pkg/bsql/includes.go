@@ -0,0 +1,178 @@
+package bsql
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	includeKey       = "include"
+	resourceTypesKey = "resource_types"
+	inlineSource     = "<inline>"
+)
+
+// loadIncludes merges root over the files it includes. Include paths are relative to dir, the directory of the
+// including file. Included files are merged in order, so later ones override earlier ones, and root overrides them all.
+// Unlike other settings, a resource type may only be defined once across all the files.
+// stack holds the files being loaded, to detect include cycles. The nodes of included files are added to sources.
+func loadIncludes(root *yaml.Node, source string, dir string, stack []string, sources nodeSources) (*yaml.Node, map[string]string, error) {
+	origins := resourceTypeOrigins(root, source)
+
+	includeNode := mappingValue(root, includeKey)
+	if includeNode == nil {
+		return root, origins, nil
+	}
+	root = withoutKey(root, includeKey, sources)
+
+	paths, err := includePaths(includeNode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	var merged *yaml.Node
+	mergedOrigins := make(map[string]string)
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		p, err = filepath.Abs(p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if slices.Contains(stack, p) {
+			return nil, nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, p), " -> "))
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to read include: %w", source, err)
+		}
+
+		var doc yaml.Node
+		err = yaml.Unmarshal(data, &doc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse include %s: %w", p, err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		sources.add(doc.Content[0], p)
+		included, includedOrigins, err := loadIncludes(doc.Content[0], p, filepath.Dir(p), append(stack, p), sources)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = mergeOrigins(mergedOrigins, includedOrigins)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if merged == nil {
+			merged = included
+		} else {
+			merged = mergeNodes(merged, included, sources)
+		}
+	}
+
+	err = mergeOrigins(mergedOrigins, origins)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if merged == nil {
+		return root, mergedOrigins, nil
+	}
+
+	return mergeNodes(merged, root, sources), mergedOrigins, nil
+}
+
+// includePaths returns the paths of an include value, which is either a single path or a list of paths.
+func includePaths(node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, nil
+	case yaml.SequenceNode:
+		ret := make([]string, 0, len(node.Content))
+		for _, n := range node.Content {
+			if n.Kind != yaml.ScalarNode || n.Value == "" {
+				return nil, fmt.Errorf("line %d: include must be a list of paths", n.Line)
+			}
+			ret = append(ret, n.Value)
+		}
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("line %d: include must be a path or a list of paths", node.Line)
+	}
+}
+
+// resourceTypeOrigins maps the resource type IDs defined directly in root to source.
+func resourceTypeOrigins(root *yaml.Node, source string) map[string]string {
+	ret := make(map[string]string)
+
+	rts := mappingValue(root, resourceTypesKey)
+	if rts == nil || rts.Kind != yaml.MappingNode {
+		return ret
+	}
+
+	for i := 0; i+1 < len(rts.Content); i += 2 {
+		ret[rts.Content[i].Value] = source
+	}
+
+	return ret
+}
+
+// mergeOrigins adds the resource types in src to dst. A resource type defined by two different files is a conflict.
+// The same file included twice is not.
+func mergeOrigins(dst map[string]string, src map[string]string) error {
+	var errs error
+	for id, source := range src {
+		if existing, ok := dst[id]; ok && existing != source {
+			errs = errors.Join(errs, fmt.Errorf("resource type %q is defined in both %s and %s", id, existing, source))
+			continue
+		}
+		dst[id] = source
+	}
+	return errs
+}
+
+// withoutKey returns a copy of the mapping node without key.
+func withoutKey(node *yaml.Node, key string, sources nodeSources) *yaml.Node {
+	ret := sources.copy(node)
+	ret.Content = make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			continue
+		}
+		ret.Content = append(ret.Content, node.Content[i], node.Content[i+1])
+	}
+	return ret
+}
+
+// nodeSources records the file that YAML nodes were read from when it is not the configuration being parsed, so that
+// errors can name it. Copies made while merging keep the source of the node they copy.
+type nodeSources map[*yaml.Node]string
+
+// add records source for node and the nodes below it.
+func (s nodeSources) add(node *yaml.Node, source string) {
+	s[node] = source
+	for _, n := range node.Content {
+		s.add(n, source)
+	}
+}
+
+// copy returns a shallow copy of node with the same source.
+func (s nodeSources) copy(node *yaml.Node) *yaml.Node {
+	ret := *node
+	if source, ok := s[node]; ok {
+		s[&ret] = source
+	}
+	return &ret
+}
@@ -0,0 +1,109 @@
+package bsql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestLoadConfigFromFile_Includes(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"app.yml": `
+include:
+  - shared/connect.yml
+  - shared/users.yml
+app_name: HR Database
+resource_types:
+  role:
+    name: "Role"
+    list:
+      query: "SELECT id FROM roles"
+      map:
+        id: ".id"
+        display_name: ".id"
+`,
+		"shared/connect.yml": `
+app_name: Shared
+connect:
+  dsn: "mysql://db:3306/app"
+`,
+		"shared/users.yml": `
+include: common.yml
+resource_types:
+  user:
+    name: "User"
+    list:
+      query: "SELECT id FROM users"
+      map:
+        id: ".id"
+        display_name: ".id"
+`,
+		"shared/common.yml": `
+app_description: Shared users
+`,
+	})
+
+	c, err := LoadConfigFromFile(filepath.Join(dir, "app.yml"))
+	require.NoError(t, err)
+	require.Equal(t, "HR Database", c.AppName)
+	require.Equal(t, "Shared users", c.AppDescription)
+	require.Equal(t, "mysql://db:3306/app", c.Connect.DSN)
+	require.Contains(t, c.ResourceTypes, "user")
+	require.Contains(t, c.ResourceTypes, "role")
+}
+
+func TestLoadConfigFromFile_IncludeCycle(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yml": "include: b.yml\n",
+		"b.yml": "include: a.yml\n",
+	})
+
+	_, err := LoadConfigFromFile(filepath.Join(dir, "a.yml"))
+	require.ErrorContains(t, err, "include cycle")
+}
+
+func TestLoadConfigFromFile_IncludeConflict(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"app.yml": `
+include: [users.yml, more_users.yml]
+`,
+		"users.yml": `
+resource_types:
+  user:
+    name: "User"
+`,
+		"more_users.yml": `
+resource_types:
+  user:
+    name: "Another user"
+`,
+	})
+
+	_, err := LoadConfigFromFile(filepath.Join(dir, "app.yml"))
+	require.ErrorContains(t, err, `resource type "user" is defined in both`)
+}
+
+func TestLoadConfigFromFile_IncludeTwice(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"app.yml":   "include: [a.yml, b.yml]\n",
+		"a.yml":     "include: users.yml\n",
+		"b.yml":     "include: users.yml\n",
+		"users.yml": "resource_types:\n  user:\n    name: \"User\"\n",
+	})
+
+	c, err := LoadConfigFromFile(filepath.Join(dir, "app.yml"))
+	require.NoError(t, err)
+	require.Contains(t, c.ResourceTypes, "user")
+}
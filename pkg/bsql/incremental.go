@@ -0,0 +1,405 @@
+package bsql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+
+	"github.com/conductorone/baton-sql/pkg/bcel/functions"
+)
+
+const (
+	sinceKey = "since"
+
+	watermarkTypeTimestamp = "timestamp"
+	watermarkTypeInteger   = "integer"
+
+	defaultFullSyncInterval = 24 * time.Hour
+)
+
+// watermarkState is the persisted state of a single incremental scan.
+type watermarkState struct {
+	// Watermark is the highest watermark value seen by the last completed scan.
+	Watermark string `json:"watermark,omitempty"`
+
+	// LastFullSync is when the last completed full scan started.
+	LastFullSync time.Time `json:"last_full_sync,omitempty"`
+}
+
+type syncStateFile struct {
+	Watermarks map[string]*watermarkState `json:"watermarks"`
+}
+
+// SyncState persists incremental sync watermarks in a local JSON file between runs.
+//
+// A scan is one run of an incremental query: the list query for one parent resource, or one grants
+// query for one resource. A scan may span several pages. Its watermark is held back until its last page
+// has been read, and the watermarks of all completed scans are only written once the whole sync has
+// completed, so an interrupted scan or sync is read again from the previous watermarks.
+//
+// The SDK has no partial sync yet, so a sync that left out unchanged rows would record them as deleted. Until it
+// does, every scan is a full read and only the watermarks are kept up to date.
+type SyncState struct {
+	path string
+
+	// partialSyncs reports whether a sync may leave out the rows an incremental read skips.
+	partialSyncs bool
+
+	mu         sync.Mutex
+	watermarks map[string]*watermarkState
+	pending    map[string]*watermarkState
+	scans      map[string]*activeScan
+	now        func() time.Time
+}
+
+// activeScan tracks a scan that is in progress in this process.
+type activeScan struct {
+	full      bool
+	startedAt time.Time
+	max       any
+}
+
+// LoadSyncState loads the sync state from path. A missing file is treated as an empty state.
+func LoadSyncState(path string) (*SyncState, error) {
+	if path == "" {
+		return nil, errors.New("state_path is required when incremental sync is configured")
+	}
+
+	ret := &SyncState{
+		path:       path,
+		watermarks: make(map[string]*watermarkState),
+		pending:    make(map[string]*watermarkState),
+		scans:      make(map[string]*activeScan),
+		now:        time.Now,
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ret, nil
+		}
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	var f syncStateFile
+	err = json.Unmarshal(b, &f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sync state %s: %w", path, err)
+	}
+	for k, v := range f.Watermarks {
+		if v != nil {
+			ret.watermarks[k] = v
+		}
+	}
+
+	return ret, nil
+}
+
+// save writes the state atomically. The caller must hold mu.
+func (st *SyncState) save() error {
+	b, err := json.MarshalIndent(syncStateFile{Watermarks: st.watermarks}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(st.path), filepath.Base(st.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(b)
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), st.path)
+	if err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+
+	return nil
+}
+
+// since returns the value for the ?<Since> token of a scan. A full scan reads from the zero watermark.
+// firstPage restarts the scan, otherwise a scan already in progress keeps its starting point.
+func (st *SyncState) since(key string, cfg *IncrementalSync, firstPage bool) (any, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	scan, ok := st.scans[key]
+	if firstPage || !ok {
+		now := st.now()
+		ws := st.watermarks[key]
+
+		interval := cfg.FullSyncInterval
+		if interval == 0 {
+			interval = defaultFullSyncInterval
+		}
+
+		scan = &activeScan{
+			startedAt: now,
+			full:      !st.partialSyncs || ws == nil || ws.Watermark == "" || now.Sub(ws.LastFullSync) >= interval,
+		}
+		st.scans[key] = scan
+	}
+
+	if scan.full {
+		return zeroWatermark(cfg.Type)
+	}
+
+	return decodeWatermark(cfg.Type, st.watermarks[key].Watermark)
+}
+
+// observe records the watermark column of a row read by the scan.
+func (st *SyncState) observe(key string, cfg *IncrementalSync, value any) error {
+	v, err := parseWatermark(cfg.Type, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for incremental column %s: %w", cfg.Column, err)
+	}
+	if v == nil {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	scan, ok := st.scans[key]
+	if !ok {
+		return nil
+	}
+	if scan.max == nil || watermarkAfter(v, scan.max) {
+		scan.max = v
+	}
+
+	return nil
+}
+
+// complete holds back the watermark of a scan once its last page has been read, until the sync is committed.
+func (st *SyncState) complete(key string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	scan, ok := st.scans[key]
+	if !ok {
+		return
+	}
+	delete(st.scans, key)
+
+	ws := &watermarkState{}
+	if prev := st.watermarks[key]; prev != nil {
+		*ws = *prev
+	}
+
+	if scan.full {
+		ws.LastFullSync = scan.startedAt
+	}
+
+	if scan.max != nil {
+		ws.Watermark = encodeWatermark(scan.max)
+	}
+
+	st.pending[key] = ws
+}
+
+// Commit writes the watermarks of the scans completed during the sync. It is called once the sync has completed.
+func (st *SyncState) Commit() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if len(st.pending) == 0 {
+		return nil
+	}
+
+	for k, ws := range st.pending {
+		st.watermarks[k] = ws
+	}
+	st.pending = make(map[string]*watermarkState)
+	st.scans = make(map[string]*activeScan)
+
+	return st.save()
+}
+
+// Discard drops the watermarks and scans of a sync that did not complete. It is called when a sync starts.
+func (st *SyncState) Discard() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pending = make(map[string]*watermarkState)
+	st.scans = make(map[string]*activeScan)
+}
+
+func zeroWatermark(typ string) (any, error) {
+	switch typ {
+	case "", watermarkTypeTimestamp:
+		return time.Unix(0, 0).UTC(), nil
+	case watermarkTypeInteger:
+		return int64(0), nil
+	default:
+		return nil, fmt.Errorf("unknown incremental watermark type %s", typ)
+	}
+}
+
+// parseWatermark converts a column value to the configured watermark type. NULL values return nil.
+func parseWatermark(typ string, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch typ {
+	case "", watermarkTypeTimestamp:
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC(), nil
+		case []byte:
+			return functions.ParseTimestamp(string(v))
+		case string:
+			return functions.ParseTimestamp(v)
+		default:
+			return nil, fmt.Errorf("unexpected type %T for a timestamp watermark", value)
+		}
+
+	case watermarkTypeInteger:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case int32:
+			return int64(v), nil
+		case int:
+			return int64(v), nil
+		case uint64:
+			return int64(v), nil
+		case uint32:
+			return int64(v), nil
+		case []byte:
+			return strconv.ParseInt(string(v), 10, 64)
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		default:
+			return nil, fmt.Errorf("unexpected type %T for an integer watermark", value)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown incremental watermark type %s", typ)
+	}
+}
+
+func decodeWatermark(typ string, s string) (any, error) {
+	switch typ {
+	case "", watermarkTypeTimestamp:
+		return time.Parse(time.RFC3339Nano, s)
+	case watermarkTypeInteger:
+		return strconv.ParseInt(s, 10, 64)
+	default:
+		return nil, fmt.Errorf("unknown incremental watermark type %s", typ)
+	}
+}
+
+func encodeWatermark(v any) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func watermarkAfter(a, b any) bool {
+	switch a := a.(type) {
+	case time.Time:
+		bt, ok := b.(time.Time)
+		return ok && a.After(bt)
+	case int64:
+		bi, ok := b.(int64)
+		return ok && a > bi
+	default:
+		return false
+	}
+}
+
+// listScanKey identifies the list scan of a resource type under a parent resource.
+func listScanKey(resourceTypeID string, parentResourceID *v2.ResourceId) string {
+	if parentResourceID == nil {
+		return resourceTypeID + "/list"
+	}
+	return fmt.Sprintf("%s/list/%s/%s", resourceTypeID, parentResourceID.GetResourceType(), parentResourceID.GetResource())
+}
+
+// grantsScanKey identifies the scan of one grants query for a resource.
+func grantsScanKey(resource *v2.Resource, queryIdx int64) string {
+	return fmt.Sprintf("%s/grants/%d/%s", resource.GetId().GetResourceType(), queryIdx, resource.GetId().GetResource())
+}
+
+// incrementalScan ties a query run to its scan in the sync state. A nil scan does nothing.
+type incrementalScan struct {
+	state *SyncState
+	key   string
+	cfg   *IncrementalSync
+}
+
+// startIncrementalScan binds the ?<Since> token of q to the scan's watermark if cfg is set.
+func (s *SQLSyncer) startIncrementalScan(key string, cfg *IncrementalSync, firstPage bool, q *syncQuery) (*incrementalScan, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	if s.state == nil {
+		return nil, errors.New("incremental sync requires state_path to be set")
+	}
+
+	if cfg.Column == "" {
+		return nil, errors.New("incremental sync requires a column")
+	}
+
+	since, err := s.state.since(key, cfg, firstPage)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Vars == nil {
+		q.Vars = make(map[string]any)
+	}
+	q.Vars[sinceKey] = since
+
+	return &incrementalScan{
+		state: s.state,
+		key:   key,
+		cfg:   cfg,
+	}, nil
+}
+
+func (i *incrementalScan) observe(rowMap map[string]any) error {
+	if i == nil {
+		return nil
+	}
+
+	v, ok := rowMap[i.cfg.Column]
+	if !ok {
+		return fmt.Errorf("incremental column %s not found in query results", i.cfg.Column)
+	}
+
+	return i.state.observe(i.key, i.cfg, v)
+}
+
+// finish completes the scan once the query has no more pages.
+func (i *incrementalScan) finish(nextPageToken string) {
+	if i == nil || nextPageToken != "" {
+		return
+	}
+
+	i.state.complete(i.key)
+}
@@ -0,0 +1,141 @@
+package bsql
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncState_Watermarks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	cfg := &IncrementalSync{Column: "updated_at", FullSyncInterval: time.Hour}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	st, err := LoadSyncState(path)
+	require.NoError(t, err)
+	st.partialSyncs = true
+	st.now = func() time.Time { return now }
+
+	// The first scan is a full read.
+	since, err := st.since("user/list", cfg, true)
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(0, 0).UTC(), since)
+
+	require.NoError(t, st.observe("user/list", cfg, []byte("2024-04-30 10:00:00")))
+	require.NoError(t, st.observe("user/list", cfg, time.Date(2024, 4, 30, 11, 0, 0, 0, time.UTC)))
+	require.NoError(t, st.observe("user/list", cfg, nil))
+
+	// A later page of the same scan keeps its starting point.
+	since, err = st.since("user/list", cfg, false)
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(0, 0).UTC(), since)
+
+	st.complete("user/list")
+
+	// A completed scan is only written once the sync is committed.
+	reloaded, err := LoadSyncState(path)
+	require.NoError(t, err)
+	require.Empty(t, reloaded.watermarks)
+	require.NoError(t, st.Commit())
+
+	// The watermark survives a reload, and the next scan is incremental.
+	now = now.Add(30 * time.Minute)
+	st, err = LoadSyncState(path)
+	require.NoError(t, err)
+	st.partialSyncs = true
+	st.now = func() time.Time { return now }
+
+	since, err = st.since("user/list", cfg, true)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 4, 30, 11, 0, 0, 0, time.UTC), since)
+
+	// An interrupted scan does not move the watermark.
+	require.NoError(t, st.observe("user/list", cfg, "2024-05-01T12:10:00Z"))
+	since, err = st.since("user/list", cfg, true)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 4, 30, 11, 0, 0, 0, time.UTC), since)
+	st.complete("user/list")
+
+	// A sync that does not complete does not move the watermark either.
+	st.Discard()
+	require.NoError(t, st.Commit())
+	since, err = st.since("user/list", cfg, true)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 4, 30, 11, 0, 0, 0, time.UTC), since)
+
+	// Once the full sync interval has passed, the next scan is a full read again.
+	now = now.Add(time.Hour)
+	since, err = st.since("user/list", cfg, true)
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(0, 0).UTC(), since)
+}
+
+func TestSyncState_IntegerWatermark(t *testing.T) {
+	st, err := LoadSyncState(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	st.partialSyncs = true
+	cfg := &IncrementalSync{Column: "version", Type: watermarkTypeInteger}
+
+	since, err := st.since("role/grants/0/admin", cfg, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), since)
+
+	require.NoError(t, st.observe("role/grants/0/admin", cfg, int64(7)))
+	require.NoError(t, st.observe("role/grants/0/admin", cfg, []byte("42")))
+	require.NoError(t, st.observe("role/grants/0/admin", cfg, int64(3)))
+	st.complete("role/grants/0/admin")
+	require.NoError(t, st.Commit())
+
+	since, err = st.since("role/grants/0/admin", cfg, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), since)
+
+	require.Error(t, st.observe("role/grants/0/admin", cfg, "not a number"))
+}
+
+func TestSyncState_FullReadsWithoutPartialSyncs(t *testing.T) {
+	st, err := LoadSyncState(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+	cfg := &IncrementalSync{Column: "version", Type: watermarkTypeInteger}
+
+	_, err = st.since("user/list", cfg, true)
+	require.NoError(t, err)
+	require.NoError(t, st.observe("user/list", cfg, int64(42)))
+	st.complete("user/list")
+	require.NoError(t, st.Commit())
+	require.Equal(t, "42", st.watermarks["user/list"].Watermark)
+
+	// The watermark is kept, but the next sync still reads every row, so none are recorded as deleted.
+	since, err := st.since("user/list", cfg, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), since)
+}
+
+func TestLoadSyncState_RequiresPath(t *testing.T) {
+	_, err := LoadSyncState("")
+	require.Error(t, err)
+}
+
+func TestParse_IncrementalRequiresFlag(t *testing.T) {
+	config := `
+state_path: /tmp/state.json
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT id FROM users WHERE updated_at >= ?<Since>
+      incremental:
+        column: updated_at
+      map:
+        id: .id
+        display_name: .id
+`
+	_, err := Parse([]byte(config))
+	require.ErrorContains(t, err, "experimental_incremental_sync")
+
+	c, err := Parse([]byte("experimental_incremental_sync: true\n" + config))
+	require.NoError(t, err)
+	require.True(t, c.UsesIncrementalSync())
+}
@@ -0,0 +1,183 @@
+package bsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+const (
+	// lookupRefreshSync reloads a lookup table at the start of every sync.
+	lookupRefreshSync = "sync"
+	// lookupRefreshOnce loads a lookup table before the first sync and keeps it for the life of the connector.
+	lookupRefreshOnce = "once"
+)
+
+// Lookups loads the lookup tables of a config and serves their rows to the lookup CEL function.
+// Tables are loaded by Load, at the start of a sync and outside any query, so that loading never runs inside a row
+// callback that holds a connection. Lookup only reads the loaded tables.
+type Lookups struct {
+	syncer  *SQLSyncer
+	configs map[string]*LookupConfig
+
+	mtx    sync.RWMutex
+	tables map[string]*lookupTable
+}
+
+type lookupTable struct {
+	rows map[string]map[string]any
+}
+
+// GetLookups returns the lookup tables of the config. It is never nil, so it can be passed to the CEL environment
+// even if no lookups are configured.
+func (c Config) GetLookups(conns DBConnections) *Lookups {
+	return &Lookups{
+		syncer: &SQLSyncer{
+			connections: conns,
+			fullConfig:  c,
+			noSnapshot:  true,
+		},
+		configs: c.Lookups,
+		tables:  make(map[string]*lookupTable),
+	}
+}
+
+// Load loads the tables for a new sync: tables with refresh: sync are reloaded, and tables with refresh: once are
+// only loaded if they have not been yet.
+func (l *Lookups) Load(ctx context.Context) error {
+	return l.load(ctx, func(cfg *LookupConfig, loaded bool) bool {
+		return !loaded || cfg.Refresh != lookupRefreshOnce
+	})
+}
+
+// EnsureLoaded loads the tables that have not been loaded yet, for lookups made outside a sync, such as by
+// provisioning or the event feed.
+func (l *Lookups) EnsureLoaded(ctx context.Context) error {
+	return l.load(ctx, func(_ *LookupConfig, loaded bool) bool {
+		return !loaded
+	})
+}
+
+func (l *Lookups) load(ctx context.Context, needsLoad func(cfg *LookupConfig, loaded bool) bool) error {
+	var errs error
+	for _, name := range sortedKeys(l.configs) {
+		cfg := l.configs[name]
+		if cfg == nil {
+			continue
+		}
+
+		l.mtx.RLock()
+		_, loaded := l.tables[name]
+		l.mtx.RUnlock()
+		if !needsLoad(cfg, loaded) {
+			continue
+		}
+
+		table, err := l.loadTable(ctx, cfg)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to load lookup table %s: %w", name, err))
+			continue
+		}
+
+		l.mtx.Lock()
+		l.tables[name] = table
+		l.mtx.Unlock()
+	}
+	return errs
+}
+
+// Lookup returns the row of the named table stored under key, or nil if there is none.
+func (l *Lookups) Lookup(name string, key any) (map[string]any, error) {
+	if cfg, ok := l.configs[name]; !ok || cfg == nil {
+		return nil, fmt.Errorf("lookup table %s is not defined in lookups", name)
+	}
+
+	k, err := lookupKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mtx.RLock()
+	table, ok := l.tables[name]
+	l.mtx.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("lookup table %s is not loaded", name)
+	}
+
+	return table.rows[k], nil
+}
+
+func (l *Lookups) loadTable(ctx context.Context, cfg *LookupConfig) (*lookupTable, error) {
+	if cfg.Query == "" {
+		return nil, errors.New("query is required")
+	}
+	if cfg.KeyColumn == "" {
+		return nil, errors.New("key_column is required")
+	}
+
+	sc, err := l.syncer.forConnection(cfg.Connection)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &lookupTable{
+		rows: make(map[string]map[string]any),
+	}
+	_, err = sc.runQuery(ctx, &pagination.Token{}, syncQuery{
+		Query:   cfg.Query,
+		Timeout: cfg.Timeout,
+		Columns: cfg.Columns,
+	}, func(ctx context.Context, rowMap map[string]any) (bool, error) {
+		v, ok := rowMap[cfg.KeyColumn]
+		if !ok {
+			return false, fmt.Errorf("key column %s not found in lookup query results", cfg.KeyColumn)
+		}
+		if v == nil {
+			return true, nil
+		}
+
+		k, err := lookupKey(v)
+		if err != nil {
+			return false, err
+		}
+		ret.rows[k] = rowMap
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// lookupKey formats a key as a string, so keys match whatever type the driver returns for the key column.
+func lookupKey(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case int32:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int:
+		return strconv.Itoa(t), nil
+	case uint64:
+		return strconv.FormatUint(t, 10), nil
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			return strconv.FormatInt(int64(t), 10), nil
+		}
+		return strconv.FormatFloat(t, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	default:
+		return "", fmt.Errorf("unsupported lookup key type %T", v)
+	}
+}
@@ -0,0 +1,153 @@
+package bsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
+	"github.com/conductorone/baton-sql/pkg/database"
+)
+
+func TestLookups_Lookup(t *testing.T) {
+	c, err := Parse([]byte(`
+lookups:
+  departments:
+    query: SELECT id, name FROM departments
+    key_column: id
+    refresh: once
+`))
+	require.NoError(t, err)
+	require.Equal(t, "id", c.Lookups["departments"].KeyColumn)
+	require.Equal(t, "once", c.Lookups["departments"].Refresh)
+
+	syncConns, _ := c.UsedConnections()
+	require.True(t, syncConns[""])
+
+	l := c.GetLookups(DBConnections{})
+
+	// Tables are only read by lookups, never loaded.
+	_, err = l.Lookup("departments", int64(7))
+	require.ErrorContains(t, err, "lookup table departments is not loaded")
+
+	l.tables["departments"] = &lookupTable{
+		rows: map[string]map[string]any{
+			"7": {"id": int64(7), "name": []byte("Engineering")},
+		},
+	}
+
+	row, err := l.Lookup("departments", int64(7))
+	require.NoError(t, err)
+	require.Equal(t, []byte("Engineering"), row["name"])
+
+	row, err = l.Lookup("departments", []byte("7"))
+	require.NoError(t, err)
+	require.NotNil(t, row)
+
+	row, err = l.Lookup("departments", 7.0)
+	require.NoError(t, err)
+	require.NotNil(t, row)
+
+	row, err = l.Lookup("departments", "8")
+	require.NoError(t, err)
+	require.Nil(t, row)
+
+	_, err = l.Lookup("roles", "admin")
+	require.ErrorContains(t, err, "lookup table roles is not defined")
+
+	_, err = Parse([]byte(`
+lookups:
+  departments:
+    query: SELECT id, name FROM departments
+    key_column: id
+    refresh: 1h
+`))
+	require.ErrorContains(t, err, `lookups.departments.refresh (line 6): invalid refresh "1h"`)
+}
+
+func TestLookups_Load(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := Parse([]byte(`
+lookups:
+  departments:
+    query: SELECT id, name FROM departments
+    key_column: id
+  roles:
+    query: SELECT code, label FROM roles
+    key_column: code
+    refresh: once
+`))
+	require.NoError(t, err)
+
+	f, db := newFakeDB()
+	defer db.Close()
+
+	names := map[string]string{"departments": "Engineering", "roles": "Administrator"}
+	f.query = func(query string, args []driver.NamedValue) (*fakeRows, error) {
+		if strings.Contains(query, "departments") {
+			return &fakeRows{columns: []string{"id", "name"}, values: [][]driver.Value{{int64(7), names["departments"]}}}, nil
+		}
+		return &fakeRows{columns: []string{"code", "label"}, values: [][]driver.Value{{"admin", names["roles"]}}}, nil
+	}
+
+	l := c.GetLookups(DBConnections{"": {DB: db, WriteDB: db, Engine: database.MySQL}})
+
+	// Provisioning before the first sync loads every table.
+	require.NoError(t, l.EnsureLoaded(ctx))
+	require.NoError(t, l.EnsureLoaded(ctx))
+	require.Len(t, f.statements(), 2)
+
+	// Each sync reloads the tables with refresh: sync, and keeps the ones with refresh: once.
+	names["departments"] = "Platform"
+	names["roles"] = "Admin"
+	require.NoError(t, l.Load(ctx))
+	require.Equal(t, []string{"SELECT id, name FROM departments", "SELECT code, label FROM roles", "SELECT id, name FROM departments"},
+		f.statements())
+
+	row, err := l.Lookup("departments", int64(7))
+	require.NoError(t, err)
+	require.Equal(t, "Platform", row["name"])
+
+	row, err = l.Lookup("roles", "admin")
+	require.NoError(t, err)
+	require.Equal(t, "Administrator", row["label"])
+}
+
+func TestLookups_celLookup(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := Parse([]byte(`
+lookups:
+  departments:
+    query: SELECT id, name FROM departments
+    key_column: id
+`))
+	require.NoError(t, err)
+
+	l := c.GetLookups(DBConnections{})
+	l.tables["departments"] = &lookupTable{
+		rows: map[string]map[string]any{
+			"7": {"id": int64(7), "name": "Engineering"},
+		},
+	}
+
+	env, err := bcel.NewEnv(ctx, bcel.WithLookupTables(l))
+	require.NoError(t, err)
+
+	inputs := env.SyncInputs(map[string]any{"dept_id": int64(7), "other_id": int64(9)})
+
+	name, err := env.EvaluateString(ctx, "lookup('departments', .dept_id).name", inputs)
+	require.NoError(t, err)
+	require.Equal(t, "Engineering", name)
+
+	missing, err := env.EvaluateBool(ctx, "lookup('departments', .other_id) == null", inputs)
+	require.NoError(t, err)
+	require.True(t, missing)
+
+	_, err = env.Evaluate(ctx, "lookup('roles', .dept_id)", inputs)
+	require.ErrorContains(t, err, "lookup table roles is not defined")
+}
@@ -0,0 +1,104 @@
+package bsql
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed presets/*.yml
+var presetFS embed.FS
+
+// Presets returns the names of the built-in presets.
+func Presets() []string {
+	entries, err := fs.Glob(presetFS, "presets/*.yml")
+	if err != nil {
+		return nil
+	}
+
+	ret := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ret = append(ret, strings.TrimSuffix(path.Base(e), ".yml"))
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// applyPreset merges the configuration root over the preset it selects, if any. The nodes of the preset are added to
+// sources.
+func applyPreset(root *yaml.Node, sources nodeSources) (*yaml.Node, error) {
+	presetNode := mappingValue(root, "preset")
+	if presetNode == nil || presetNode.Value == "" {
+		return root, nil
+	}
+
+	name := presetNode.Value
+	data, err := presetFS.ReadFile("presets/" + name + ".yml")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("unknown preset %q, available presets are: %s", name, strings.Join(Presets(), ", "))
+		}
+		return nil, err
+	}
+
+	var preset yaml.Node
+	err = yaml.Unmarshal(data, &preset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse preset %s: %w", name, err)
+	}
+
+	sources.add(preset.Content[0], "preset "+name)
+	return mergeNodes(preset.Content[0], root, sources), nil
+}
+
+// mergeNodes merges override into base. Mappings are merged key by key, and any other value in override,
+// including lists, replaces the one in base. Merged mappings keep the source of base.
+func mergeNodes(base *yaml.Node, override *yaml.Node, sources nodeSources) *yaml.Node {
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	ret := sources.copy(base)
+	ret.Content = append([]*yaml.Node(nil), base.Content...)
+
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+
+		found := false
+		for j := 0; j+1 < len(ret.Content); j += 2 {
+			if ret.Content[j].Value == key.Value {
+				ret.Content[j+1] = mergeNodes(ret.Content[j+1], value, sources)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			ret.Content = append(ret.Content, key, value)
+		}
+	}
+
+	return ret
+}
+
+// mappingValue returns the value of key in a mapping node, or nil if it is not set.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package bsql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresets(t *testing.T) {
+	require.Equal(t, []string{"mysql-native", "oracle-native", "sqlserver-native"}, Presets())
+
+	for _, name := range Presets() {
+		t.Run(name, func(t *testing.T) {
+			c, err := Parse([]byte("preset: " + name + "\nconnect:\n  dsn: \"x://db\"\n"))
+			require.NoError(t, err)
+			require.Equal(t, name, c.Preset)
+			require.Equal(t, "x://db", c.Connect.DSN)
+
+			require.Contains(t, c.ResourceTypes, "user")
+			require.Contains(t, c.ResourceTypes, "role")
+
+			role := c.ResourceTypes["role"]
+			require.NotEmpty(t, role.Grants)
+			for _, g := range role.Grants {
+				// Each role lists only its own members rather than every membership edge.
+				require.Contains(t, g.Query, "?<Resource_ID>")
+			}
+			require.NotEmpty(t, role.StaticEntitlements)
+			for _, e := range role.StaticEntitlements {
+				require.NotNil(t, e.Provisioning)
+				require.NotEmpty(t, e.Provisioning.Grant.Queries)
+				require.NotEmpty(t, e.Provisioning.Revoke.Queries)
+				for _, q := range append(e.Provisioning.Grant.Queries, e.Provisioning.Revoke.Queries...) {
+					require.NotContains(t, q.Query, "unquoted")
+				}
+			}
+		})
+	}
+}
+
+func TestPresets_mysqlNestedRoles(t *testing.T) {
+	c, err := Parse([]byte("preset: mysql-native\n"))
+	require.NoError(t, err)
+
+	// Roles granted to roles are listed as role principals instead of being dropped.
+	var principalTypes []string
+	for _, m := range c.ResourceTypes["role"].Grants[0].Map {
+		principalTypes = append(principalTypes, m.PrincipalType)
+	}
+	require.Contains(t, principalTypes, "role")
+	require.Contains(t, c.ResourceTypes["role"].StaticEntitlements[0].GrantableTo, "role")
+}
+
+func TestParse_PresetOverride(t *testing.T) {
+	c, err := Parse([]byte(`
+preset: oracle-native
+app_name: HR Database
+resource_types:
+  user:
+    list:
+      query: SELECT USERNAME, USER_ID, ACCOUNT_STATUS, CREATED FROM DBA_USERS WHERE ORACLE_MAINTAINED = 'N'
+  profile:
+    name: Profile
+    list:
+      query: SELECT DISTINCT PROFILE FROM DBA_PROFILES
+`))
+	require.NoError(t, err)
+
+	require.Equal(t, "HR Database", c.AppName)
+	require.Equal(t, "Oracle database users and roles", c.AppDescription)
+
+	user := c.ResourceTypes["user"]
+	require.Equal(t, "User", user.Name)
+	require.Contains(t, user.List.Query, "ORACLE_MAINTAINED")
+	require.Equal(t, ".USERNAME", user.List.Map.Id)
+
+	require.Contains(t, c.ResourceTypes, "role")
+	require.Equal(t, "Profile", c.ResourceTypes["profile"].Name)
+
+	// Lines name the preset when the value comes from it.
+	require.Equal(t, "resource_types.user.list.query (line 7)", c.location("resource_types.user.list.query"))
+	require.Regexp(t, `^resource_types\.user\.list\.map\.id \(preset oracle-native, line \d+\)$`, c.location("resource_types.user.list.map.id"))
+}
+
+func TestParse_UnknownPreset(t *testing.T) {
+	_, err := Parse([]byte("preset: postgres-native\n"))
+	require.ErrorContains(t, err, "unknown preset")
+}
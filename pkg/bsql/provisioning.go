@@ -67,11 +67,12 @@ func (s *SQLSyncer) Grant(ctx context.Context, principal *v2.Resource, entitleme
 		return nil, err
 	}
 
-	useTx := true
-	if provisioningConfig.Grant.NoTransaction {
-		useTx = false
+	sc, err := s.forConnection(provisioningConfig.Connection)
+	if err != nil {
+		return nil, err
 	}
-	err = s.runProvisioningQueries(ctx, provisioningConfig.Grant.Queries, provisioningVars, useTx)
+
+	err = sc.runProvisioningQueries(ctx, provisioningConfig.Grant, provisioningVars, provisioningConfig.Keywords)
 	if err != nil {
 		return nil, err
 	}
@@ -115,12 +116,12 @@ func (s *SQLSyncer) Revoke(ctx context.Context, grant *v2.Grant) (annotations.An
 		return nil, err
 	}
 
-	useTx := true
-	if provisioningConfig.Revoke.NoTransaction {
-		useTx = false
+	sc, err := s.forConnection(provisioningConfig.Connection)
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.runProvisioningQueries(ctx, provisioningConfig.Revoke.Queries, provisioningVars, useTx)
+	err = sc.runProvisioningQueries(ctx, provisioningConfig.Revoke, provisioningVars, provisioningConfig.Keywords)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,87 @@
+package bsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductorone/baton-sql/pkg/database"
+)
+
+func Test_runProvisioningQueries_retry(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	tests := []struct {
+		name          string
+		noTransaction bool
+		maxAttempts   int
+		// failOn maps the number of a statement, counted from 1 across attempts, to its error.
+		failOn  map[int]error
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no errors",
+			want: []string{"BEGIN", "q1", "q2", "q3", "COMMIT"},
+		},
+		{
+			name:   "retries the whole transaction",
+			failOn: map[int]error{2: deadlock},
+			want: []string{
+				"BEGIN", "q1", "q2", "ROLLBACK",
+				"BEGIN", "q1", "q2", "q3", "COMMIT",
+			},
+		},
+		{
+			name:          "resumes at the failed statement without a transaction",
+			noTransaction: true,
+			failOn:        map[int]error{2: deadlock, 3: deadlock},
+			want:          []string{"q1", "q2", "q2", "q2", "q3"},
+		},
+		{
+			name:    "does not retry other errors",
+			failOn:  map[int]error{2: errors.New("syntax error")},
+			want:    []string{"BEGIN", "q1", "q2", "ROLLBACK"},
+			wantErr: true,
+		},
+		{
+			name:        "gives up after the last attempt",
+			maxAttempts: 2,
+			failOn:      map[int]error{1: deadlock, 2: deadlock},
+			want:        []string{"BEGIN", "q1", "ROLLBACK", "BEGIN", "q1", "ROLLBACK"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, db := newFakeDB()
+			defer db.Close()
+			f.exec = func(n int, query string, args []driver.NamedValue) error {
+				return tt.failOn[n]
+			}
+
+			s := &SQLSyncer{db: db, writeDB: db, dbEngine: database.MySQL}
+			pq := &EntitlementProvisioningQueries{
+				NoTransaction: tt.noTransaction,
+				Queries:       []*ProvisioningStep{{Query: "q1"}, {Query: "q2"}, {Query: "q3"}},
+				Retry: &RetryConfig{
+					MaxAttempts:    tt.maxAttempts,
+					InitialBackoff: time.Millisecond,
+				},
+			}
+
+			err := s.runProvisioningQueries(context.Background(), pq, map[string]any{}, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, f.statements())
+		})
+	}
+}
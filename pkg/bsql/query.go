@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
@@ -24,10 +25,25 @@ const (
 	cursorKey       = "cursor"
 	limitKey        = "limit"
 	unquotedKey     = "unquoted"
+	identKey        = "ident"
+	accountKey      = "account"
+	keywordKey      = "keyword"
+	resourceIDKey   = "resource_id"
+
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+)
+
+const (
+	cursorTypeString    = "string"
+	cursorTypeInteger   = "integer"
+	cursorTypeTimestamp = "timestamp"
 )
 
 type executor interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
 type paginationContext struct {
@@ -35,12 +51,63 @@ type paginationContext struct {
 	Limit      int64
 	Offset     int64
 	Cursor     string
+	CursorType string
 	PrimaryKey string
 }
 
+// cursorValue returns the value the ?<Cursor> token is bound to, converted to the cursor type.
+// Without a cursor it is the zero value of the type.
+func (p *paginationContext) cursorValue() (any, error) {
+	switch p.CursorType {
+	case "", cursorTypeString:
+		return p.Cursor, nil
+	case cursorTypeInteger:
+		if p.Cursor == "" {
+			return int64(0), nil
+		}
+		v, err := strconv.ParseInt(p.Cursor, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer cursor %q: %w", p.Cursor, err)
+		}
+		return v, nil
+	case cursorTypeTimestamp:
+		if p.Cursor == "" {
+			return time.Unix(0, 0).UTC(), nil
+		}
+		v, err := time.Parse(time.RFC3339Nano, p.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp cursor %q: %w", p.Cursor, err)
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unknown cursor type %s", p.CursorType)
+	}
+}
+
+// syncQuery describes a sync query and how to run it.
+type syncQuery struct {
+	Query      string
+	Pagination *Pagination
+	Timeout    time.Duration
+
+	// Vars holds the values of query tokens other than the pagination tokens.
+	Vars map[string]any
+
+	// Columns holds the configured conversions of column values, by column name.
+	Columns map[string]string
+}
+
 type queryTokenOpts struct {
 	Key      string
 	Unquoted bool
+	Ident    bool
+	Account  bool
+	Keyword  bool
+}
+
+// inline reports whether the token value is written into the query text instead of being bound as a parameter.
+func (o *queryTokenOpts) inline() bool {
+	return o.Unquoted || o.Ident || o.Account || o.Keyword
 }
 
 var queryOptRegex = regexp.MustCompile(`\?\<([a-zA-Z0-9_]+)(?:\|([a-zA-Z0-9_]+))?\>`)
@@ -87,6 +154,12 @@ func parseToken(token string) (*queryTokenOpts, error) {
 		switch opt {
 		case unquotedKey:
 			opts.Unquoted = true
+		case identKey:
+			opts.Ident = true
+		case accountKey:
+			opts.Account = true
+		case keywordKey:
+			opts.Keyword = true
 		default:
 			return nil, fmt.Errorf("unknown option %s", opt)
 		}
@@ -95,8 +168,34 @@ func parseToken(token string) (*queryTokenOpts, error) {
 	return opts, nil
 }
 
-func (s *SQLSyncer) parseQueryOpts(ctx context.Context, pCtx *paginationContext, query string) (string, []interface{}, bool, error) {
-	if pCtx == nil {
+// inlineValue renders a token value that is written into the query text. Identifiers and accounts are
+// validated and quoted for the engine, and keywords must be in the allow-list of the var in keywords.
+// Unquoted values are written as is, which the config must explicitly allow.
+func (s *SQLSyncer) inlineValue(opts *queryTokenOpts, val any, keywords map[string][]string) (string, error) {
+	if opts.Unquoted {
+		if !s.fullConfig.AllowUnquoted {
+			return "", errors.New("the unquoted option is disabled, use ident or keyword instead, or set allow_unquoted: true")
+		}
+		return fmt.Sprintf("%v", val), nil
+	}
+
+	str := fmt.Sprintf("%v", val)
+	if b, ok := val.([]byte); ok {
+		str = string(b)
+	}
+
+	switch {
+	case opts.Account:
+		return database.QuoteAccount(s.dbEngine, str)
+	case opts.Keyword:
+		return keywordValue(opts.Key, str, keywords[opts.Key])
+	default:
+		return database.QuoteIdentifier(s.dbEngine, str)
+	}
+}
+
+func (s *SQLSyncer) parseQueryOpts(ctx context.Context, pCtx *paginationContext, query string, vars map[string]any) (string, []interface{}, bool, error) {
+	if pCtx == nil && len(vars) == 0 {
 		return query, nil, false, nil
 	}
 
@@ -112,6 +211,14 @@ func (s *SQLSyncer) parseQueryOpts(ctx context.Context, pCtx *paginationContext,
 		}
 
 		var val interface{}
+		switch opts.Key {
+		case limitKey, offsetKey, cursorKey:
+			if pCtx == nil {
+				parseErr = errors.Join(parseErr, fmt.Errorf("token %s requires pagination to be configured", token))
+				return token
+			}
+		}
+
 		switch opts.Key {
 		case limitKey:
 			// Always request 1 more than the specified limit, so we can see if there are additional results.
@@ -121,16 +228,29 @@ func (s *SQLSyncer) parseQueryOpts(ctx context.Context, pCtx *paginationContext,
 			val = pCtx.Offset
 			paginationOptSet = true
 		case cursorKey:
-			val = pCtx.Cursor
+			val, err = pCtx.cursorValue()
+			if err != nil {
+				parseErr = errors.Join(parseErr, fmt.Errorf("in token %s: %w", token, err))
+				return token
+			}
 			paginationOptSet = true
 		default:
-			parseErr = errors.Join(parseErr, fmt.Errorf("unknown token %s", token))
-			return token
+			v, ok := vars[opts.Key]
+			if !ok {
+				parseErr = errors.Join(parseErr, fmt.Errorf("unknown token %s", token))
+				return token
+			}
+			val = v
 		}
 
-		// If the value is unquoted, directly insert the value as a string
-		if opts.Unquoted {
-			return fmt.Sprintf("%v", val)
+		// Inlined values are written directly into the query
+		if opts.inline() {
+			v, err := s.inlineValue(opts, val, nil)
+			if err != nil {
+				parseErr = errors.Join(parseErr, fmt.Errorf("in token %s: %w", token, err))
+				return token
+			}
+			return v
 		}
 
 		qArgs = append(qArgs, val)
@@ -156,13 +276,13 @@ func clampPageSize(pageSize int) int64 {
 	return int64(pageSize)
 }
 
-func (s *SQLSyncer) prepareQuery(ctx context.Context, pToken *pagination.Token, query string, pOpts *Pagination) (string, []interface{}, *paginationContext, error) {
+func (s *SQLSyncer) prepareQuery(ctx context.Context, pToken *pagination.Token, query string, pOpts *Pagination, vars map[string]any) (string, []interface{}, *paginationContext, error) {
 	pCtx, err := s.setupPagination(ctx, pToken, pOpts)
 	if err != nil {
 		return "", nil, nil, err
 	}
 
-	q, qArgs, paginationUsed, err := s.parseQueryOpts(ctx, pCtx, query)
+	q, qArgs, paginationUsed, err := s.parseQueryOpts(ctx, pCtx, query, vars)
 	if err != nil {
 		return "", nil, nil, err
 	}
@@ -187,33 +307,10 @@ func (s *SQLSyncer) nextPageToken(ctx context.Context, pCtx *paginationContext,
 	case offsetKey:
 		ret = strconv.Itoa(int(pCtx.Offset)*pageSize + pageSize)
 	case cursorKey:
-		switch l := lastRowID.(type) {
-		case string:
-			ret = l
-		case []byte:
-			ret = string(l)
-		case int64:
-			ret = strconv.FormatInt(l, 10)
-		case int:
-			ret = strconv.Itoa(l)
-		case int32:
-			ret = strconv.FormatInt(int64(l), 10)
-		case int16:
-			ret = strconv.FormatInt(int64(l), 10)
-		case int8:
-			ret = strconv.FormatInt(int64(l), 10)
-		case uint64:
-			ret = strconv.FormatUint(l, 10)
-		case uint:
-			ret = strconv.FormatUint(uint64(l), 10)
-		case uint32:
-			ret = strconv.FormatUint(uint64(l), 10)
-		case uint16:
-			ret = strconv.FormatUint(uint64(l), 10)
-		case uint8:
-			ret = strconv.FormatUint(uint64(l), 10)
-		default:
-			return "", errors.New("unexpected type for primary key")
+		var err error
+		ret, err = formatCursor(lastRowID)
+		if err != nil {
+			return "", err
 		}
 	default:
 		return "", fmt.Errorf("unexpected pagination strategy: %s", pCtx.Strategy)
@@ -222,6 +319,42 @@ func (s *SQLSyncer) nextPageToken(ctx context.Context, pCtx *paginationContext,
 	return ret, nil
 }
 
+// formatCursor converts the cursor column value of a row into a page token.
+func formatCursor(lastRowID any) (string, error) {
+	switch l := lastRowID.(type) {
+	case string:
+		return l, nil
+	case []byte:
+		return string(l), nil
+	case int64:
+		return strconv.FormatInt(l, 10), nil
+	case int:
+		return strconv.Itoa(l), nil
+	case int32:
+		return strconv.FormatInt(int64(l), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(l), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(l), 10), nil
+	case uint64:
+		return strconv.FormatUint(l, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(l), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(l), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(l), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(l), 10), nil
+	case float64:
+		return strconv.FormatFloat(l, 'f', -1, 64), nil
+	case time.Time:
+		return l.UTC().Format(time.RFC3339Nano), nil
+	default:
+		return "", errors.New("unexpected type for primary key")
+	}
+}
+
 func (s *SQLSyncer) setupPagination(ctx context.Context, pToken *pagination.Token, pOpts *Pagination) (*paginationContext, error) {
 	if pOpts == nil {
 		return nil, nil
@@ -230,6 +363,7 @@ func (s *SQLSyncer) setupPagination(ctx context.Context, pToken *pagination.Toke
 	ret := &paginationContext{
 		Strategy:   pOpts.Strategy,
 		PrimaryKey: pOpts.PrimaryKey,
+		CursorType: pOpts.CursorType,
 	}
 
 	ret.Limit = clampPageSize(pToken.Size)
@@ -256,7 +390,34 @@ func (s *SQLSyncer) setupPagination(ctx context.Context, pToken *pagination.Toke
 	return ret, nil
 }
 
-func (s *SQLSyncer) prepareProvisioningQuery(ctx context.Context, query string, vars map[string]any) (string, []interface{}, error) {
+// keywordValue returns the entry of allowed that matches val, ignoring case. Keyword phrases cannot be quoted,
+// so only the values listed for the var are accepted, which stops a value such as "DBA TO scott" from changing
+// the statement.
+func keywordValue(key string, val string, allowed []string) (string, error) {
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("the keyword option requires a keywords allow-list for %s", key)
+	}
+
+	for _, kw := range allowed {
+		if !strings.EqualFold(kw, val) {
+			continue
+		}
+		err := database.ValidateKeyword(kw)
+		if err != nil {
+			return "", err
+		}
+		return kw, nil
+	}
+
+	return "", fmt.Errorf("%q is not in the keywords allow-list for %s", val, key)
+}
+
+func (s *SQLSyncer) prepareProvisioningQuery(
+	ctx context.Context,
+	query string,
+	vars map[string]any,
+	keywords map[string][]string,
+) (string, []interface{}, error) {
 	var qArgs []interface{}
 
 	var parseErr error
@@ -273,8 +434,14 @@ func (s *SQLSyncer) prepareProvisioningQuery(ctx context.Context, query string,
 			return token
 		}
 
-		if opts.Unquoted {
-			return fmt.Sprintf("%v", v)
+		// Inlined values are written directly into the query
+		if opts.inline() {
+			iv, err := s.inlineValue(opts, v, keywords)
+			if err != nil {
+				parseErr = errors.Join(parseErr, fmt.Errorf("in token %s: %w", token, err))
+				return token
+			}
+			return iv
 		}
 
 		qArgs = append(qArgs, v)
@@ -286,16 +453,96 @@ func (s *SQLSyncer) prepareProvisioningQuery(ctx context.Context, query string,
 	return updatedQuery, qArgs, nil
 }
 
-func (s *SQLSyncer) runProvisioningQueries(ctx context.Context, queries []string, vars map[string]any, useTx bool) error {
+func (s *SQLSyncer) runProvisioningQueries(
+	ctx context.Context,
+	pq *EntitlementProvisioningQueries,
+	vars map[string]any,
+	keywords map[string][]string,
+) error {
+	l := ctxzap.Extract(ctx)
+
+	isolation, err := database.ParseIsolationLevel(pq.IsolationLevel)
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := 1
+	backoff := defaultRetryInitialBackoff
+	maxBackoff := defaultRetryMaxBackoff
+	if pq.Retry != nil {
+		maxAttempts = defaultRetryMaxAttempts
+		if pq.Retry.MaxAttempts > 0 {
+			maxAttempts = pq.Retry.MaxAttempts
+		}
+		if pq.Retry.InitialBackoff > 0 {
+			backoff = pq.Retry.InitialBackoff
+		}
+		if pq.Retry.MaxBackoff > 0 {
+			maxBackoff = pq.Retry.MaxBackoff
+		}
+	}
+
+	start := 0
+	for attempt := 1; ; attempt++ {
+		failedAt, err := s.execProvisioningQueries(ctx, pq, vars, keywords, isolation, start)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= maxAttempts || !database.IsRetryableError(s.dbEngine, err) {
+			return err
+		}
+
+		// Without a transaction the queries before the failed one have already been applied,
+		// so the retry resumes at the query that failed. Otherwise everything was rolled back.
+		if pq.NoTransaction {
+			start = failedAt
+		}
+
+		l.Warn(
+			"retrying provisioning queries after retryable error",
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// execProvisioningQueries runs the provisioning queries starting at index start.
+// If a query fails, its index is returned along with the error.
+func (s *SQLSyncer) execProvisioningQueries(
+	ctx context.Context,
+	pq *EntitlementProvisioningQueries,
+	vars map[string]any,
+	keywords map[string][]string,
+	isolation sql.IsolationLevel,
+	start int,
+) (int, error) {
 	l := ctxzap.Extract(ctx)
 
+	if pq.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pq.Timeout)
+		defer cancel()
+	}
+
+	useTx := !pq.NoTransaction
+
 	var committed bool
-	var executor executor = s.db
+	var executor executor
 
 	if useTx {
-		tx, err := s.db.Begin()
+		tx, err := database.BeginTx(ctx, s.writeDB, s.dbEngine, &sql.TxOptions{Isolation: isolation})
 		if err != nil {
-			return err
+			return start, err
 		}
 		executor = tx
 
@@ -306,17 +553,38 @@ func (s *SQLSyncer) runProvisioningQueries(ctx context.Context, queries []string
 				}
 			}
 		}()
+	} else {
+		// Run every step on one connection, so session state such as MySQL OUT parameters carries over.
+		conn, err := s.writeDB.Conn(ctx)
+		if err != nil {
+			return start, err
+		}
+		defer conn.Close()
+		executor = conn
 	}
 
-	for _, q := range queries {
-		q, qArgs, err := s.prepareProvisioningQuery(ctx, q, vars)
+	for ii := start; ii < len(pq.Queries); ii++ {
+		step := pq.Queries[ii]
+		if step == nil {
+			return ii, errors.New("missing provisioning step")
+		}
+
+		if step.Procedure != nil {
+			err := s.callProcedure(ctx, executor, step.Procedure, vars)
+			if err != nil {
+				return ii, err
+			}
+			continue
+		}
+
+		q, qArgs, err := s.prepareProvisioningQuery(ctx, step.Query, vars, keywords)
 		if err != nil {
-			return err
+			return ii, err
 		}
 
 		result, err := executor.ExecContext(ctx, q, qArgs...)
 		if err != nil {
-			return err
+			return ii, err
 		}
 
 		rowsAffected, err := result.RowsAffected()
@@ -325,7 +593,7 @@ func (s *SQLSyncer) runProvisioningQueries(ctx context.Context, queries []string
 		}
 
 		if rowsAffected > 1 {
-			return errors.New("query affected more than one row, ending and rolling back")
+			return ii, errors.New("query affected more than one row, ending and rolling back")
 		}
 
 		l.Debug("query executed", zap.String("query", q), zap.Any("args", qArgs), zap.Int64("rows_affected", rowsAffected), zap.Bool("use_tx", useTx))
@@ -334,41 +602,210 @@ func (s *SQLSyncer) runProvisioningQueries(ctx context.Context, queries []string
 	if useTx {
 		tx, ok := executor.(*sql.Tx)
 		if !ok {
-			return errors.New("transactional executor required")
+			return start, errors.New("transactional executor required")
 		}
 		err := tx.Commit()
 		if err != nil {
-			return err
+			return start, err
 		}
 		committed = true
 	}
 
+	return len(pq.Queries), nil
+}
+
+// callProcedure calls a stored procedure provisioning step. OUT parameter values are stored in vars, so later
+// steps can use them.
+func (s *SQLSyncer) callProcedure(ctx context.Context, ex executor, pc *ProcedureCall, vars map[string]any) error {
+	l := ctxzap.Extract(ctx)
+
+	params := make([]database.ProcedureParam, 0, len(pc.Params))
+	for _, p := range pc.Params {
+		if p == nil {
+			return fmt.Errorf("missing parameter for procedure %s", pc.Name)
+		}
+
+		param := database.ProcedureParam{
+			Name: p.Name,
+			Type: p.Type,
+		}
+
+		switch p.Direction {
+		case "", "in":
+			param.In = true
+		case "out":
+			param.Out = true
+		case "inout":
+			param.In = true
+			param.Out = true
+		default:
+			return fmt.Errorf("unknown direction %q for parameter %s of procedure %s", p.Direction, p.Name, pc.Name)
+		}
+
+		if param.In {
+			v, ok := vars[paramVar(p)]
+			if !ok {
+				return fmt.Errorf("unknown var %s for parameter %s of procedure %s", paramVar(p), p.Name, pc.Name)
+			}
+			param.Value = v
+		}
+
+		params = append(params, param)
+	}
+
+	res, err := database.CallProcedure(ctx, ex, s.dbEngine, pc.Name, params)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pc.Params {
+		if v, ok := res.Out[p.Name]; ok {
+			vars[paramVar(p)] = v
+		}
+	}
+
+	l.Debug("procedure called", zap.String("procedure", pc.Name), zap.Any("out", res.Out), zap.Int64p("return_status", res.ReturnStatus))
+
+	if pc.Success == "" {
+		if res.ReturnStatus != nil && *res.ReturnStatus != 0 {
+			return fmt.Errorf("procedure %s returned status %d", pc.Name, *res.ReturnStatus)
+		}
+		return nil
+	}
+
+	cols := make(map[string]any, len(vars)+1)
+	for k, v := range vars {
+		cols[k] = v
+	}
+	if res.ReturnStatus != nil {
+		cols["return_status"] = *res.ReturnStatus
+	}
+
+	ok, err := s.env.EvaluateBool(ctx, pc.Success, s.env.SyncInputs(cols))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate success expression for procedure %s: %w", pc.Name, err)
+	}
+	if !ok {
+		return fmt.Errorf("procedure %s reported failure", pc.Name)
+	}
+
 	return nil
 }
 
+func paramVar(p *ProcedureParam) string {
+	if p.Var != "" {
+		return p.Var
+	}
+	return p.Name
+}
+
+// dbError is an error returned by the database while running a sync query.
+type dbError struct {
+	err error
+}
+
+func (e *dbError) Error() string {
+	return e.err.Error()
+}
+
+func (e *dbError) Unwrap() error {
+	return e.err
+}
+
+// breaksSnapshot reports whether a failed sync query can have left the snapshot transaction unusable. That is the case
+// for database errors, but not for mapping errors or timeouts.
+func breaksSnapshot(err error) bool {
+	var dbErr *dbError
+	if !errors.As(err, &dbErr) {
+		return false
+	}
+	return !errors.Is(err, database.ErrSnapshotReset) &&
+		!errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// query runs a sync query, inside the consistent snapshot if one is configured.
+// The returned release function must be called once the rows have been closed.
+func (s *SQLSyncer) query(ctx context.Context, q string, qArgs ...any) (*sql.Rows, func(), error) {
+	if s.snapshot == nil {
+		rows, err := s.db.QueryContext(ctx, q, qArgs...)
+		return rows, func() {}, err
+	}
+
+	tx, release, err := s.snapshot.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, q, qArgs...)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	return rows, release, nil
+}
+
 func (s *SQLSyncer) runQuery(
 	ctx context.Context,
 	pToken *pagination.Token,
-	query string,
-	pOpts *Pagination,
+	q syncQuery,
+	rowCallback func(context.Context, map[string]interface{}) (bool, error),
+) (string, error) {
+	queryCtx := ctx
+	if q.Timeout > 0 {
+		var cancel context.CancelFunc
+		queryCtx, cancel = context.WithTimeout(ctx, q.Timeout)
+		defer cancel()
+	}
+
+	npt, err := s.execQuery(queryCtx, pToken, q, rowCallback)
+	if err != nil {
+		if s.snapshot != nil && breaksSnapshot(err) {
+			s.snapshot.Reset()
+			err = fmt.Errorf("%w: %w", database.ErrSnapshotReset, err)
+		}
+
+		if ctx.Err() == nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) {
+			if s.resourceType == nil {
+				return "", fmt.Errorf("query timed out after %s: %w", q.Timeout, err)
+			}
+			return "", fmt.Errorf("query for resource type %s timed out after %s: %w", s.resourceType.GetId(), q.Timeout, err)
+		}
+		return "", err
+	}
+
+	return npt, nil
+}
+
+func (s *SQLSyncer) execQuery(
+	ctx context.Context,
+	pToken *pagination.Token,
+	sq syncQuery,
 	rowCallback func(context.Context, map[string]interface{}) (bool, error),
 ) (string, error) {
 	l := ctxzap.Extract(ctx)
 
-	q, qArgs, pCtx, err := s.prepareQuery(ctx, pToken, query, pOpts)
+	q, qArgs, pCtx, err := s.prepareQuery(ctx, pToken, sq.Query, sq.Pagination, sq.Vars)
 	if err != nil {
 		return "", err
 	}
 
 	l.Debug("running query", zap.String("query", q), zap.Any("args", qArgs))
 
-	rows, err := s.db.QueryContext(ctx, q, qArgs...)
+	rows, release, err := s.query(ctx, q, qArgs...)
 	if err != nil {
-		return "", err
+		return "", &dbError{err}
 	}
+	defer release()
 	defer rows.Close()
 
 	columns, err := rows.Columns()
+	if err != nil {
+		return "", &dbError{err}
+	}
+
+	converters, err := s.columnConverters(rows, sq.Columns)
 	if err != nil {
 		return "", err
 	}
@@ -389,15 +826,19 @@ func (s *SQLSyncer) runQuery(
 		}
 
 		if err := rows.Scan(scanArgs...); err != nil {
-			return "", err
+			return "", &dbError{err}
 		}
 
 		foundPaginationKey := false
 		rowMap := make(map[string]interface{})
 		for i, colName := range columns {
-			rowMap[colName] = values[i]
+			v, err := converters[i](values[i])
+			if err != nil {
+				return "", fmt.Errorf("failed to convert column %s: %w", colName, err)
+			}
+			rowMap[colName] = v
 			if pCtx != nil && pCtx.PrimaryKey == colName {
-				lastRowID = values[i]
+				lastRowID = v
 				foundPaginationKey = true
 			}
 		}
@@ -416,7 +857,7 @@ func (s *SQLSyncer) runQuery(
 	}
 
 	if err := rows.Err(); err != nil {
-		return "", err
+		return "", &dbError{err}
 	}
 
 	nextPageToken := ""
@@ -429,3 +870,32 @@ func (s *SQLSyncer) runQuery(
 
 	return nextPageToken, nil
 }
+
+// columnConverters returns a converter for each column of rows, which turns the values the driver returns into
+// the natural CEL types for the column's database type, or into the type configured for the column.
+func (s *SQLSyncer) columnConverters(rows *sql.Rows, hints map[string]string) ([]database.ValueConverter, error) {
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, &dbError{err}
+	}
+
+	found := make(map[string]bool, len(colTypes))
+	ret := make([]database.ValueConverter, len(colTypes))
+	for i, ct := range colTypes {
+		found[ct.Name()] = true
+		col := database.Column{DatabaseType: ct.DatabaseTypeName()}
+		_, col.Scale, col.ScaleOK = ct.DecimalSize()
+		ret[i], err = database.ColumnConverter(s.dbEngine, col, hints[ct.Name()])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", ct.Name(), err)
+		}
+	}
+
+	for name := range hints {
+		if !found[name] {
+			return nil, fmt.Errorf("column %s is configured in columns but not returned by the query", name)
+		}
+	}
+
+	return ret, nil
+}
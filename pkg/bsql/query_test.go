@@ -2,9 +2,16 @@ package bsql
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
 	"github.com/conductorone/baton-sql/pkg/database"
 )
 
@@ -42,6 +49,24 @@ func Test_parseToken(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:  "Token with ident option",
+			token: "?<role_name|ident>",
+			want: &queryTokenOpts{
+				Key:   "role_name",
+				Ident: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Token with account option",
+			token: "?<principal|Account>",
+			want: &queryTokenOpts{
+				Key:     "principal",
+				Account: true,
+			},
+			wantErr: false,
+		},
 		{
 			name:    "Invalid token format",
 			token:   "invalid",
@@ -313,9 +338,10 @@ func Test_parseQueryOpts(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ss := &SQLSyncer{
-				dbEngine: tt.dbEngine,
+				dbEngine:   tt.dbEngine,
+				fullConfig: Config{AllowUnquoted: true},
 			}
-			query, queryArgs, paginationUsed, err := ss.parseQueryOpts(tt.args.ctx, tt.args.pCtx, tt.args.query)
+			query, queryArgs, paginationUsed, err := ss.parseQueryOpts(tt.args.ctx, tt.args.pCtx, tt.args.query, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseQueryOpts() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -332,3 +358,330 @@ func Test_parseQueryOpts(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseQueryOptsVars(t *testing.T) {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	vars := map[string]any{sinceKey: since}
+
+	tests := []struct {
+		name     string
+		dbEngine database.DbEngine
+		pCtx     *paginationContext
+		query    string
+		want     string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "since without pagination",
+			dbEngine: database.MySQL,
+			query:    "SELECT * FROM users WHERE updated_at >= ?<Since>",
+			want:     "SELECT * FROM users WHERE updated_at >= ?",
+			wantArgs: []interface{}{since},
+		},
+		{
+			name:     "since with pagination",
+			dbEngine: database.MSSQL,
+			pCtx:     &paginationContext{Limit: 10},
+			query:    "SELECT TOP (?<Limit>) * FROM users WHERE updated_at >= ?<Since>",
+			want:     "SELECT TOP (@p1) * FROM users WHERE updated_at >= @p2",
+			wantArgs: []interface{}{int64(11), since},
+		},
+		{
+			name:     "pagination token without pagination",
+			dbEngine: database.MySQL,
+			query:    "SELECT * FROM users WHERE updated_at >= ?<Since> LIMIT ?<Limit>",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown token",
+			dbEngine: database.MySQL,
+			query:    "SELECT * FROM users WHERE updated_at >= ?<Until>",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := &SQLSyncer{
+				dbEngine: tt.dbEngine,
+			}
+			query, queryArgs, _, err := ss.parseQueryOpts(context.Background(), tt.pCtx, tt.query, vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQueryOpts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if query != tt.want {
+				t.Errorf("parseQueryOpts() got = %v, want %v", query, tt.want)
+			}
+			if !reflect.DeepEqual(tt.wantArgs, queryArgs) {
+				t.Errorf("parseQueryOpts() got = %v, want %v", queryArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func Test_prepareProvisioningQuery(t *testing.T) {
+	vars := map[string]any{
+		"role":      "db_owner",
+		"principal": "alice]; DROP LOGIN sa; --",
+		"account":   "alice@10.0.0.%",
+		"privilege": "create session",
+		"injected":  "DBA TO scott",
+	}
+	keywords := map[string][]string{
+		"privilege": {"CREATE SESSION", "CREATE TABLE"},
+		"injected":  {"CREATE SESSION"},
+	}
+
+	tests := []struct {
+		name     string
+		dbEngine database.DbEngine
+		query    string
+		want     string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "ident on SQL Server",
+			dbEngine: database.MSSQL,
+			query:    "ALTER SERVER ROLE ?<role|ident> ADD MEMBER ?<principal|ident>",
+			want:     "ALTER SERVER ROLE [db_owner] ADD MEMBER [alice]]; DROP LOGIN sa; --]",
+		},
+		{
+			name:     "ident on Oracle",
+			dbEngine: database.Oracle,
+			query:    "GRANT ?<role|ident> TO ?<role>",
+			want:     `GRANT "db_owner" TO :1`,
+			wantArgs: []interface{}{"db_owner"},
+		},
+		{
+			name:     "account on MySQL",
+			dbEngine: database.MySQL,
+			query:    "GRANT ?<role|ident> TO ?<account|account>",
+			want:     "GRANT `db_owner` TO `alice`@`10.0.0.%`",
+		},
+		{
+			name:     "account outside MySQL",
+			dbEngine: database.Oracle,
+			query:    "GRANT ?<role|ident> TO ?<account|account>",
+			wantErr:  true,
+		},
+		{
+			name:     "keyword",
+			dbEngine: database.Oracle,
+			query:    "GRANT ?<privilege|keyword> TO ?<role|ident>",
+			want:     `GRANT CREATE SESSION TO "db_owner"`,
+		},
+		{
+			name:     "keyword outside the allow-list",
+			dbEngine: database.Oracle,
+			query:    "GRANT ?<injected|keyword> TO ?<role|ident>",
+			wantErr:  true,
+		},
+		{
+			name:     "keyword without an allow-list",
+			dbEngine: database.Oracle,
+			query:    "GRANT ?<principal|keyword> TO ?<role|ident>",
+			wantErr:  true,
+		},
+		{
+			name:     "unquoted is disabled by default",
+			dbEngine: database.Oracle,
+			query:    "GRANT ?<role|unquoted> TO ?<role|ident>",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown var",
+			dbEngine: database.MySQL,
+			query:    "GRANT ?<missing|ident> TO ?<account|account>",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := &SQLSyncer{
+				dbEngine: tt.dbEngine,
+			}
+			query, queryArgs, err := ss.prepareProvisioningQuery(context.Background(), tt.query, vars, keywords)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepareProvisioningQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if query != tt.want {
+				t.Errorf("prepareProvisioningQuery() got = %v, want %v", query, tt.want)
+			}
+			if !reflect.DeepEqual(tt.wantArgs, queryArgs) {
+				t.Errorf("prepareProvisioningQuery() got = %v, want %v", queryArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func Test_forConnection(t *testing.T) {
+	ss := &SQLSyncer{
+		connections: DBConnections{
+			"":     {Engine: database.MySQL},
+			"apps": {Engine: database.MSSQL},
+			"hr":   {Engine: database.Oracle},
+		},
+	}
+
+	tests := []struct {
+		connection string
+		want       string
+		wantErr    bool
+	}{
+		{"", "SELECT * FROM users WHERE id > ? LIMIT ?", false},
+		{"apps", "SELECT * FROM users WHERE id > @p1 LIMIT @p2", false},
+		{"hr", "SELECT * FROM users WHERE id > :1 LIMIT :2", false},
+		{"missing", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.connection, func(t *testing.T) {
+			sc, err := ss.forConnection(tt.connection)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("forConnection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			query, _, _, err := sc.parseQueryOpts(context.Background(), &paginationContext{Limit: 10}, "SELECT * FROM users WHERE id > ?<Cursor> LIMIT ?<Limit>", nil)
+			if err != nil {
+				t.Fatalf("parseQueryOpts() error = %v", err)
+			}
+			if query != tt.want {
+				t.Errorf("parseQueryOpts() got = %v, want %v", query, tt.want)
+			}
+		})
+	}
+}
+
+func Test_snapshotScope(t *testing.T) {
+	ctx := context.Background()
+
+	f, db := newFakeDB()
+	defer db.Close()
+	snapshot := database.NewSnapshot(db, database.MySQL, nil, 0)
+	conns := DBConnections{"": {DB: db, WriteDB: db, Engine: database.MySQL, Snapshot: snapshot}}
+
+	run := func(s *SQLSyncer, q string) {
+		sc, err := s.forConnection("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = sc.runQuery(ctx, &pagination.Token{}, syncQuery{Query: q}, func(context.Context, map[string]any) (bool, error) {
+			return true, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	syncer := &SQLSyncer{connections: conns}
+	run(syncer, "list")
+	run(syncer, "grants")
+	run(Config{Events: &EventsConfig{}}.GetEventFeed(conns, nil).syncer, "events")
+	run(Config{}.GetLookups(conns).syncer, "lookup")
+
+	// The next sync starts a new snapshot.
+	if err := snapshot.Close(); err != nil {
+		t.Fatal(err)
+	}
+	run(syncer, "list")
+
+	want := []string{"BEGIN", "list", "grants", "events", "lookup", "ROLLBACK", "BEGIN", "list"}
+	if got := f.statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %v, want %v", got, want)
+	}
+}
+
+func Test_runQuery_snapshotReset(t *testing.T) {
+	ctx := context.Background()
+
+	f, db := newFakeDB()
+	defer db.Close()
+	snapshot := database.NewSnapshot(db, database.MySQL, nil, 0)
+	sc := &SQLSyncer{db: db, dbEngine: database.MySQL, snapshot: snapshot}
+
+	ok := func(context.Context, map[string]any) (bool, error) { return true, nil }
+	run := func(q string, cb func(context.Context, map[string]any) (bool, error)) error {
+		_, err := sc.runQuery(ctx, &pagination.Token{}, syncQuery{Query: q}, cb)
+		return err
+	}
+
+	f.query = func(query string, args []driver.NamedValue) (*fakeRows, error) {
+		if query == "broken" {
+			return nil, errors.New("connection lost")
+		}
+		return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(1)}}}, nil
+	}
+
+	// Mapping errors keep the snapshot.
+	err := run("list", func(context.Context, map[string]any) (bool, error) { return false, errors.New("bad mapping") })
+	if err == nil || errors.Is(err, database.ErrSnapshotReset) {
+		t.Fatalf("mapping error = %v, want an error that keeps the snapshot", err)
+	}
+	if err := run("grants", ok); err != nil {
+		t.Fatal(err)
+	}
+
+	// Database errors discard it, and later queries fail instead of reading from a new snapshot.
+	if err := run("broken", ok); !errors.Is(err, database.ErrSnapshotReset) {
+		t.Fatalf("database error = %v, want ErrSnapshotReset", err)
+	}
+	if err := run("grants", ok); !errors.Is(err, database.ErrSnapshotReset) {
+		t.Fatalf("query after reset = %v, want ErrSnapshotReset", err)
+	}
+
+	// The next sync starts over.
+	if err := snapshot.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("list", ok); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"BEGIN", "list", "grants", "broken", "ROLLBACK", "BEGIN", "list"}
+	if got := f.statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %v, want %v", got, want)
+	}
+}
+
+func Test_listGrants_resourceID(t *testing.T) {
+	ctx := context.Background()
+	env, err := bcel.NewEnv(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, db := newFakeDB()
+	defer db.Close()
+
+	var args []any
+	f.query = func(query string, a []driver.NamedValue) (*fakeRows, error) {
+		for _, v := range a {
+			args = append(args, v.Value)
+		}
+		return &fakeRows{columns: []string{"member"}, values: [][]driver.Value{{"alice"}}}, nil
+	}
+
+	s := &SQLSyncer{connections: DBConnections{"": {DB: db, WriteDB: db, Engine: database.MySQL}}, env: env}
+	role := &v2.Resource{Id: &v2.ResourceId{ResourceType: "role", Resource: "admins"}}
+	grants, _, err := s.listGrants(ctx, role, &pagination.Token{}, &GrantsQuery{
+		Query: "SELECT member FROM role_members WHERE role = ?<Resource_ID>",
+		Map:   []*GrantMapping{{PrincipalId: ".member", PrincipalType: "user", Entitlement: "'member'"}},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 1 {
+		t.Fatalf("got %d grants, want 1", len(grants))
+	}
+	if !reflect.DeepEqual(args, []any{"admins"}) {
+		t.Errorf("args = %v, want [admins]", args)
+	}
+}
@@ -44,6 +44,10 @@ func (c Config) extractTraits(rtID string) ([]v2.ResourceType_Trait, error) {
 		traits = append(traits, v2.ResourceType_TRAIT_APP)
 	}
 
+	if rt.List.Map.Traits.Secret != nil {
+		traits = append(traits, v2.ResourceType_TRAIT_SECRET)
+	}
+
 	return traits, nil
 }
 
@@ -3,10 +3,12 @@ package bsql
 import (
 	"context"
 	"errors"
-	"strings"
+	"fmt"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
@@ -21,7 +23,29 @@ func (s *SQLSyncer) List(ctx context.Context, parentResourceID *v2.ResourceId, p
 		return nil, "", nil, errors.New("no resource list configuration provided")
 	}
 
-	npt, err := s.runQuery(ctx, pToken, s.config.List.Query, s.config.List.Pagination, func(ctx context.Context, rowMap map[string]any) (bool, error) {
+	sc, err := s.forConnection(s.config.List.Connection)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	q := syncQuery{
+		Query:      s.config.List.Query,
+		Pagination: s.config.List.Pagination,
+		Timeout:    s.config.List.Timeout,
+		Columns:    s.config.List.Columns,
+	}
+
+	scan, err := s.startIncrementalScan(listScanKey(s.resourceType.GetId(), parentResourceID), s.config.List.Incremental, pToken.Token == "", &q)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	npt, err := sc.runQuery(ctx, pToken, q, func(ctx context.Context, rowMap map[string]any) (bool, error) {
+		err := scan.observe(rowMap)
+		if err != nil {
+			return false, err
+		}
+
 		r, err := s.mapResource(ctx, rowMap)
 		if err != nil {
 			return false, err
@@ -33,26 +57,33 @@ func (s *SQLSyncer) List(ctx context.Context, parentResourceID *v2.ResourceId, p
 		return nil, "", nil, err
 	}
 
+	scan.finish(npt)
+
 	return ret, npt, nil, nil
 }
 
 func (s *SQLSyncer) fetchTraits(ctx context.Context) map[string]bool {
 	traits := make(map[string]bool)
 	mapTraits := s.config.List.Map.Traits
-	if mapTraits != nil {
-		switch {
-		case mapTraits.User != nil:
-			traits[userTraitType] = true
-
-		case mapTraits.Group != nil:
-			traits[groupTraitType] = true
-
-		case mapTraits.Role != nil:
-			traits[roleTraitType] = true
+	if mapTraits == nil {
+		return traits
+	}
 
-		case mapTraits.App != nil:
-			traits[appTraitType] = true
-		}
+	// A resource can have several traits, e.g. a service account that is both a user and a secret.
+	if mapTraits.User != nil {
+		traits[userTraitType] = true
+	}
+	if mapTraits.Group != nil {
+		traits[groupTraitType] = true
+	}
+	if mapTraits.Role != nil {
+		traits[roleTraitType] = true
+	}
+	if mapTraits.App != nil {
+		traits[appTraitType] = true
+	}
+	if mapTraits.Secret != nil {
+		traits[secretTraitType] = true
 	}
 
 	return traits
@@ -95,25 +126,12 @@ func (s *SQLSyncer) mapUserTrait(ctx context.Context, r *v2.Resource, rowMap map
 			return err
 		}
 
-		var status v2.UserTrait_Status_Status
-		switch strings.ToLower(statusValue) {
-		case "active":
-			status = v2.UserTrait_Status_STATUS_ENABLED
-		case "enabled":
-			status = v2.UserTrait_Status_STATUS_ENABLED
-		case "disabled":
-			status = v2.UserTrait_Status_STATUS_DISABLED
-		case "inactive":
-			status = v2.UserTrait_Status_STATUS_DISABLED
-		case "suspended":
-			status = v2.UserTrait_Status_STATUS_DISABLED
-		case "locked":
-			status = v2.UserTrait_Status_STATUS_DISABLED
-		case "deleted":
-			status = v2.UserTrait_Status_STATUS_DELETED
-		default:
+		status, ok, err := mappings.userStatus(statusValue)
+		if err != nil {
+			return err
+		}
+		if !ok {
 			l.Warn("unexpected status value in mapping", zap.String("status", statusValue))
-			status = v2.UserTrait_Status_STATUS_UNSPECIFIED
 		}
 
 		if mappings.StatusDetails != "" {
@@ -146,19 +164,12 @@ func (s *SQLSyncer) mapUserTrait(ctx context.Context, r *v2.Resource, rowMap map
 			return err
 		}
 
-		var accountType v2.UserTrait_AccountType
-		switch strings.ToLower(v) {
-		case "user":
-			accountType = v2.UserTrait_ACCOUNT_TYPE_HUMAN
-		case "human":
-			accountType = v2.UserTrait_ACCOUNT_TYPE_HUMAN
-		case "service":
-			accountType = v2.UserTrait_ACCOUNT_TYPE_SERVICE
-		case "system":
-			accountType = v2.UserTrait_ACCOUNT_TYPE_SYSTEM
-		default:
+		accountType, ok, err := mappings.accountType(v)
+		if err != nil {
+			return err
+		}
+		if !ok {
 			l.Warn("unexpected account type value in mapping, defaulting to human", zap.String("account_type", v))
-			accountType = v2.UserTrait_ACCOUNT_TYPE_HUMAN
 		}
 		opts = append(opts, sdkResource.WithAccountType(accountType))
 	} else {
@@ -185,6 +196,21 @@ func (s *SQLSyncer) mapUserTrait(ctx context.Context, r *v2.Resource, rowMap map
 		opts = append(opts, sdkResource.WithUserLogin(primaryLogin, aliases...))
 	}
 
+	if mappings.LastLogin != "" {
+		v, err := s.env.Evaluate(ctx, mappings.LastLogin, inputs)
+		if err != nil {
+			return err
+		}
+
+		if !isEmptyValue(v) {
+			lastLogin, err := toTime(v)
+			if err != nil {
+				return fmt.Errorf("invalid last_login for user %s: %w", r.GetId().GetResource(), err)
+			}
+			opts = append(opts, sdkResource.WithLastLogin(lastLogin))
+		}
+	}
+
 	t, err := sdkResource.NewUserTrait(opts...)
 	if err != nil {
 		return err
@@ -299,6 +325,112 @@ func (s *SQLSyncer) mapRoleTrait(ctx context.Context, r *v2.Resource, rowMap map
 	return nil
 }
 
+func (s *SQLSyncer) mapSecretTrait(ctx context.Context, r *v2.Resource, rowMap map[string]any) error {
+	inputs := s.env.SyncInputs(rowMap)
+
+	mappings := s.config.List.Map.Traits.Secret
+
+	var opts []sdkResource.SecretTraitOption
+
+	if mappings.Owner != nil {
+		if mappings.Owner.ID == "" {
+			return errors.New("missing owner ID mapping for secret trait")
+		}
+
+		ownerID, err := s.env.Evaluate(ctx, mappings.Owner.ID, inputs)
+		if err != nil {
+			return err
+		}
+
+		if !isEmptyValue(ownerID) {
+			resourceType := mappings.Owner.ResourceType
+			if resourceType == "" {
+				resourceType = userTraitType
+			}
+			opts = append(opts, sdkResource.WithSecretCreatedByID(&v2.ResourceId{
+				ResourceType: resourceType,
+				Resource:     valueString(ownerID),
+			}))
+		}
+	}
+
+	for _, m := range []struct {
+		name string
+		expr string
+		opt  func(time.Time) sdkResource.SecretTraitOption
+	}{
+		{"created_at", mappings.CreatedAt, sdkResource.WithSecretCreatedAt},
+		{"expires_at", mappings.ExpiresAt, sdkResource.WithSecretExpiresAt},
+		{"last_used_at", mappings.LastUsedAt, sdkResource.WithSecretLastUsedAt},
+	} {
+		if m.expr == "" {
+			continue
+		}
+
+		v, err := s.env.Evaluate(ctx, m.expr, inputs)
+		if err != nil {
+			return err
+		}
+		if isEmptyValue(v) {
+			continue
+		}
+
+		t, err := toTime(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s for secret %s: %w", m.name, r.GetId().GetResource(), err)
+		}
+		opts = append(opts, m.opt(t))
+	}
+
+	t, err := sdkResource.NewSecretTrait(opts...)
+	if err != nil {
+		return err
+	}
+
+	profile := make(map[string]interface{})
+	for profileKey, profileValue := range mappings.Profile {
+		v, err := s.env.EvaluateString(ctx, profileValue, inputs)
+		if err != nil {
+			return err
+		}
+		profile[profileKey] = v
+	}
+	if len(profile) > 0 {
+		t.Profile, err = structpb.NewStruct(profile)
+		if err != nil {
+			return err
+		}
+	}
+
+	annos := annotations.Annotations(r.Annotations)
+	annos.Update(t)
+	r.Annotations = annos
+
+	return nil
+}
+
+// isEmptyValue reports whether a CEL result is null or empty.
+func isEmptyValue(v any) bool {
+	switch t := v.(type) {
+	case nil, structpb.NullValue:
+		return true
+	case string:
+		return t == ""
+	case []byte:
+		return len(t) == 0
+	default:
+		return false
+	}
+}
+
+// valueString formats a CEL result as a string.
+func valueString(v any) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
 func (s *SQLSyncer) mapTraits(ctx context.Context, r *v2.Resource, rowMap map[string]any) error {
 	l := ctxzap.Extract(ctx)
 
@@ -324,6 +456,10 @@ func (s *SQLSyncer) mapTraits(ctx context.Context, r *v2.Resource, rowMap map[st
 			if err := s.mapGroupTrait(ctx, r, rowMap); err != nil {
 				return err
 			}
+		case secretTraitType:
+			if err := s.mapSecretTrait(ctx, r, rowMap); err != nil {
+				return err
+			}
 		default:
 			l.Warn("unexpected trait type in mapping", zap.String("trait", trait))
 			continue
@@ -0,0 +1,157 @@
+package bsql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductorone/baton-sql/pkg/bcel"
+)
+
+func TestSQLSyncer_mapResource_secret(t *testing.T) {
+	ctx := context.Background()
+	env, err := bcel.NewEnv(ctx)
+	require.NoError(t, err)
+
+	s := &SQLSyncer{
+		resourceType: &v2.ResourceType{Id: "api_key"},
+		env:          env,
+		config: ResourceType{
+			List: &ListQuery{
+				Map: &ResourceMapping{
+					Id:          ".id",
+					DisplayName: ".name",
+					Traits: &Traits{
+						Secret: &SecretTraitMapping{
+							Owner:      &SecretOwner{ID: ".user_id"},
+							CreatedAt:  ".created_at",
+							ExpiresAt:  ".expires_at",
+							LastUsedAt: ".last_used_at",
+							Profile:    map[string]string{"scope": ".scope"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, err := s.mapResource(ctx, map[string]any{
+		"id":           "key-1",
+		"name":         "CI token",
+		"user_id":      []byte("42"),
+		"created_at":   createdAt,
+		"expires_at":   "2025-01-02 03:04:05",
+		"last_used_at": nil,
+		"scope":        "read",
+	})
+	require.NoError(t, err)
+
+	secret := &v2.SecretTrait{}
+	annos := annotations.Annotations(r.Annotations)
+	ok, err := annos.Pick(secret)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, &v2.ResourceId{ResourceType: "user", Resource: "42"}, secret.GetCreatedById())
+	require.Equal(t, createdAt, secret.GetCreatedAt().AsTime())
+	require.Equal(t, createdAt.AddDate(1, 0, 0), secret.GetExpiresAt().AsTime())
+	require.Nil(t, secret.GetLastUsedAt())
+	require.Equal(t, "read", secret.GetProfile().GetFields()["scope"].GetStringValue())
+}
+
+func TestSQLSyncer_mapResource_userAndSecret(t *testing.T) {
+	ctx := context.Background()
+	env, err := bcel.NewEnv(ctx)
+	require.NoError(t, err)
+
+	s := &SQLSyncer{
+		resourceType: &v2.ResourceType{Id: "service_account"},
+		env:          env,
+		config: ResourceType{
+			List: &ListQuery{
+				Map: &ResourceMapping{
+					Id:          ".id",
+					DisplayName: ".name",
+					Traits: &Traits{
+						User: &UserTraitMapping{
+							Login: ".name",
+						},
+						Secret: &SecretTraitMapping{
+							Owner:     &SecretOwner{ID: ".owner_id"},
+							CreatedAt: ".created_at",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, err := s.mapResource(ctx, map[string]any{
+		"id":         "svc-1",
+		"name":       "deploy-bot",
+		"owner_id":   "42",
+		"created_at": createdAt,
+	})
+	require.NoError(t, err)
+
+	annos := annotations.Annotations(r.Annotations)
+
+	user := &v2.UserTrait{}
+	ok, err := annos.Pick(user)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "deploy-bot", user.GetLogin())
+
+	secret := &v2.SecretTrait{}
+	ok, err = annos.Pick(secret)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, &v2.ResourceId{ResourceType: "user", Resource: "42"}, secret.GetCreatedById())
+	require.Equal(t, createdAt, secret.GetCreatedAt().AsTime())
+}
+
+func TestSQLSyncer_mapResource_lastLogin(t *testing.T) {
+	ctx := context.Background()
+	env, err := bcel.NewEnv(ctx)
+	require.NoError(t, err)
+
+	s := &SQLSyncer{
+		resourceType: &v2.ResourceType{Id: "user"},
+		env:          env,
+		config: ResourceType{
+			List: &ListQuery{
+				Map: &ResourceMapping{
+					Id:          ".id",
+					DisplayName: ".name",
+					Traits: &Traits{
+						User: &UserTraitMapping{
+							LastLogin: ".last_login",
+							Profile:   map[string]string{"created": ".created_at"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r, err := s.mapResource(ctx, map[string]any{
+		"id":         int64(1),
+		"name":       "alice",
+		"last_login": []byte("2024-01-02 03:04:05"),
+		"created_at": time.Date(2023, 5, 6, 9, 8, 7, 0, time.FixedZone("EST", -5*3600)),
+	})
+	require.NoError(t, err)
+
+	user := &v2.UserTrait{}
+	annos := annotations.Annotations(r.Annotations)
+	ok, err := annos.Pick(user)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), user.GetLastLogin().AsTime())
+	require.Equal(t, "2023-05-06T14:08:07Z", user.GetProfile().GetFields()["created"].GetStringValue())
+}
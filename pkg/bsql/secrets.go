@@ -0,0 +1,201 @@
+package bsql
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"gopkg.in/yaml.v3"
+
+	"github.com/conductorone/baton-sql/pkg/database"
+)
+
+// connectionKeys are the keys of the connection settings that database.Connect expands itself. File references and
+// encrypted values in them are left in place: file references are substituted after environment variables and
+// escaped in the DSN, and encrypted values are decrypted on connect, so their plaintext is never expanded.
+var connectionKeys = map[string]bool{
+	"dsn":      true,
+	"user":     true,
+	"password": true,
+}
+
+// Option configures how a configuration is loaded.
+type Option func(*loadOptions) error
+
+type loadOptions struct {
+	identities []age.Identity
+
+	// path is the absolute path of the configuration file, if it was loaded from one.
+	path string
+}
+
+// WithAgeIdentityFile loads the age identities used to decrypt ENC[...] values from path.
+func WithAgeIdentityFile(path string) Option {
+	return func(o *loadOptions) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open age identity file: %w", err)
+		}
+		defer f.Close()
+
+		ids, err := age.ParseIdentities(f)
+		if err != nil {
+			return fmt.Errorf("failed to parse age identity file %s: %w", path, err)
+		}
+		o.identities = append(o.identities, ids...)
+		return nil
+	}
+}
+
+func newLoadOptions(opts []Option) (*loadOptions, error) {
+	ret := &loadOptions{}
+	for _, opt := range opts {
+		err := opt(ret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// resolveSecrets replaces secret references in every scalar value of the document:
+// ${file:/path} is replaced with the contents of the file, without a trailing newline, and a value
+// of the form ENC[...] is replaced with its decrypted content. Encrypted values are base64 encoded age files.
+// File references and encrypted values in the connection settings under connect are only checked here, and
+// substituted on connect.
+func resolveSecrets(node *yaml.Node, opts *loadOptions) error {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return resolveNode(node, opts, false)
+	}
+
+	var errs error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		errs = errors.Join(errs, resolveNode(node.Content[i], opts, false))
+		errs = errors.Join(errs, resolveNode(node.Content[i+1], opts, node.Content[i].Value == "connect"))
+	}
+	return errs
+}
+
+// resolveNode resolves the secrets below node. inConnect is set below the connect key.
+func resolveNode(node *yaml.Node, opts *loadOptions, inConnect bool) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return resolveScalar(node, opts, false)
+	case yaml.MappingNode:
+		var errs error
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			errs = errors.Join(errs, resolveNode(key, opts, inConnect))
+			if inConnect && value.Kind == yaml.ScalarNode && connectionKeys[key.Value] {
+				errs = errors.Join(errs, resolveScalar(value, opts, true))
+				continue
+			}
+			errs = errors.Join(errs, resolveNode(value, opts, inConnect))
+		}
+		return errs
+	default:
+		var errs error
+		for _, child := range node.Content {
+			errs = errors.Join(errs, resolveNode(child, opts, inConnect))
+		}
+		return errs
+	}
+}
+
+// resolveScalar resolves the secret references in a scalar. If keepRefs is set, file references are only checked
+// to be readable and encrypted values to decrypt, and both are left for database.Connect.
+func resolveScalar(node *yaml.Node, opts *loadOptions, keepRefs bool) error {
+	value := node.Value
+	changed := false
+
+	if m := database.EncryptedRegex.FindStringSubmatch(value); m != nil {
+		plaintext, err := decryptValue(m[1], opts.identities)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if keepRefs {
+			return nil
+		}
+		value = plaintext
+		changed = true
+	} else if keepRefs {
+		var err error
+		for _, m := range database.FileRefRegex.FindAllStringSubmatch(value, -1) {
+			if strings.Contains(m[1], "$") {
+				// The path uses an environment variable, which is only expanded on connect.
+				continue
+			}
+			_, readErr := os.ReadFile(m[1])
+			if readErr != nil {
+				err = errors.Join(err, fmt.Errorf("line %d: failed to read %s: %w", node.Line, m[0], readErr))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	} else if database.FileRefRegex.MatchString(value) {
+		var err error
+		value = database.FileRefRegex.ReplaceAllStringFunc(value, func(ref string) string {
+			path := database.FileRefRegex.FindStringSubmatch(ref)[1]
+			b, readErr := os.ReadFile(path)
+			if readErr != nil {
+				err = errors.Join(err, fmt.Errorf("line %d: failed to read %s: %w", node.Line, ref, readErr))
+				return ref
+			}
+			return strings.TrimRight(string(b), "\r\n")
+		})
+		if err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if changed {
+		// The tag is kept, so a secret is a string even if it reads as null, a number or a bool.
+		node.Value = value
+	}
+
+	return nil
+}
+
+// DecryptConnectionValue returns the plaintext of an ENC[...] connection setting, using the age identities the
+// config was loaded with. Other values are returned as they are.
+func (c *Config) DecryptConnectionValue(value string) (string, error) {
+	m := database.EncryptedRegex.FindStringSubmatch(value)
+	if m == nil {
+		return value, nil
+	}
+	return decryptValue(m[1], c.identities)
+}
+
+func decryptValue(encoded string, identities []age.Identity) (string, error) {
+	if len(identities) == 0 {
+		return "", errors.New("encrypted value found but no age identity file was provided")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), identities...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
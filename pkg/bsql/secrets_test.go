@@ -0,0 +1,103 @@
+package bsql
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptValue(t *testing.T, recipient age.Recipient, plaintext string) string {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(plaintext))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return "ENC[" + base64.StdEncoding.EncodeToString(buf.Bytes()) + "]"
+}
+
+func TestParse_FileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	passwordPath := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordPath, []byte("s3cret\n"), 0o600))
+
+	dsn := "mysql://app:${file:" + passwordPath + "}@db:3306/app"
+	c, err := Parse([]byte(`
+app_description: "${file:` + passwordPath + `}"
+connect:
+  dsn: "` + dsn + `"
+  password: "${file:` + passwordPath + `}"
+`))
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", c.AppDescription)
+	// Connection settings keep their references, which are substituted on connect after environment variables.
+	require.Equal(t, dsn, c.Connect.DSN)
+	require.Equal(t, "${file:"+passwordPath+"}", c.Connect.Password)
+
+	_, err = Parse([]byte(`
+connect:
+  dsn: "${file:` + filepath.Join(dir, "missing") + `}"
+`))
+	require.ErrorContains(t, err, "line 3")
+}
+
+func TestParse_EncryptedSecrets(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	identityPath := filepath.Join(dir, "key.txt")
+	require.NoError(t, os.WriteFile(identityPath, []byte(identity.String()+"\n"), 0o600))
+
+	config := []byte(`
+connect:
+  dsn: "mysql://db:3306/app"
+  password: ` + encryptValue(t, identity.Recipient(), "s3cret") + `
+`)
+
+	c, err := Parse(config, WithAgeIdentityFile(identityPath))
+	require.NoError(t, err)
+	// Connection settings are decrypted on connect, so their plaintext is never expanded.
+	require.Regexp(t, `^ENC\[`, c.Connect.Password)
+	password, err := c.DecryptConnectionValue(c.Connect.Password)
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", password)
+	dsn, err := c.DecryptConnectionValue(c.Connect.DSN)
+	require.NoError(t, err)
+	require.Equal(t, "mysql://db:3306/app", dsn)
+
+	_, err = Parse(config)
+	require.ErrorContains(t, err, "no age identity file")
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	otherPath := filepath.Join(dir, "other.txt")
+	require.NoError(t, os.WriteFile(otherPath, []byte(other.String()+"\n"), 0o600))
+
+	_, err = Parse(config, WithAgeIdentityFile(otherPath))
+	require.ErrorContains(t, err, "failed to decrypt value")
+
+	_, err = Parse(config, WithAgeIdentityFile(filepath.Join(dir, "missing.txt")))
+	require.Error(t, err)
+}
+
+func TestParse_EncryptedSecretsAreStrings(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	identityPath := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(identityPath, []byte(identity.String()+"\n"), 0o600))
+
+	for _, plaintext := range []string{"null", "~", "true", "123"} {
+		c, err := Parse([]byte("app_description: "+encryptValue(t, identity.Recipient(), plaintext)+"\n"),
+			WithAgeIdentityFile(identityPath))
+		require.NoError(t, err)
+		require.Equal(t, plaintext, c.AppDescription)
+	}
+}
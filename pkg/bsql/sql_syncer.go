@@ -3,6 +3,8 @@ package bsql
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
@@ -11,26 +13,53 @@ import (
 )
 
 const (
-	userTraitType  = "user"
-	appTraitType   = "app"
-	groupTraitType = "group"
-	roleTraitType  = "role"
+	userTraitType   = "user"
+	appTraitType    = "app"
+	groupTraitType  = "group"
+	roleTraitType   = "role"
+	secretTraitType = "secret"
 )
 
+// DBConnection bundles the database handles the syncers use.
+type DBConnection struct {
+	// DB serves sync queries.
+	DB *sql.DB
+
+	// WriteDB serves provisioning queries. It is the same as DB unless a separate write connection is configured.
+	WriteDB *sql.DB
+
+	// Engine is the database engine behind both handles.
+	Engine database.DbEngine
+
+	// Snapshot, if set, runs sync queries inside a consistent read-only transaction on DB.
+	Snapshot *database.Snapshot
+}
+
+// DBConnections maps connection names to their database handles. The default connection has an empty name.
+type DBConnections map[string]*DBConnection
+
 type SQLSyncer struct {
 	resourceType *v2.ResourceType
 	db           *sql.DB
+	writeDB      *sql.DB
 	dbEngine     database.DbEngine
+	snapshot     *database.Snapshot
+	state        *SyncState
+	connections  DBConnections
 	config       ResourceType
 	env          *bcel.Env
 	fullConfig   Config
+
+	// noSnapshot runs the queries outside the sync snapshot, for queries that are not part of a sync.
+	noSnapshot bool
 }
 
 func (s *SQLSyncer) ResourceType(ctx context.Context) *v2.ResourceType {
 	return s.resourceType
 }
 
-func (c Config) GetSQLSyncers(ctx context.Context, db *sql.DB, dbEngine database.DbEngine, celEnv *bcel.Env) ([]connectorbuilder.ResourceSyncer, error) {
+// GetSQLSyncers returns a syncer for each resource type. state may be nil if no query uses incremental sync.
+func (c Config) GetSQLSyncers(ctx context.Context, conns DBConnections, celEnv *bcel.Env, state *SyncState) ([]connectorbuilder.ResourceSyncer, error) {
 	var ret []connectorbuilder.ResourceSyncer
 	for rtID, rtConfig := range c.ResourceTypes {
 		rt, err := c.GetResourceType(ctx, rtID)
@@ -41,13 +70,41 @@ func (c Config) GetSQLSyncers(ctx context.Context, db *sql.DB, dbEngine database
 		rv := &SQLSyncer{
 			resourceType: rt,
 			config:       rtConfig,
-			db:           db,
-			dbEngine:     dbEngine,
+			connections:  conns,
+			state:        state,
 			env:          celEnv,
 			fullConfig:   c,
 		}
+		if conn, ok := conns[""]; ok {
+			rv.bindConnection(conn)
+		}
 		ret = append(ret, rv)
 	}
 
 	return ret, nil
 }
+
+func (s *SQLSyncer) bindConnection(conn *DBConnection) {
+	s.db = conn.DB
+	s.writeDB = conn.WriteDB
+	s.dbEngine = conn.Engine
+	if !s.noSnapshot {
+		s.snapshot = conn.Snapshot
+	}
+}
+
+// forConnection returns a copy of the syncer that runs its queries on the named connection.
+// An empty name selects the default connection.
+func (s *SQLSyncer) forConnection(name string) (*SQLSyncer, error) {
+	conn, ok := s.connections[name]
+	if !ok {
+		if name == "" {
+			return nil, errors.New("no default connection configured")
+		}
+		return nil, fmt.Errorf("connection %s is not defined in connect.connections", name)
+	}
+
+	ret := *s
+	ret.bindConnection(conn)
+	return &ret, nil
+}
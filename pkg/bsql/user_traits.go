@@ -0,0 +1,152 @@
+package bsql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+)
+
+// unmappedError makes values missing from the status or account type tables fail the sync.
+const unmappedError = "error"
+
+// userStatuses are the statuses that status values can map to.
+var userStatuses = map[string]v2.UserTrait_Status_Status{
+	"enabled":     v2.UserTrait_Status_STATUS_ENABLED,
+	"disabled":    v2.UserTrait_Status_STATUS_DISABLED,
+	"deleted":     v2.UserTrait_Status_STATUS_DELETED,
+	"unspecified": v2.UserTrait_Status_STATUS_UNSPECIFIED,
+}
+
+// builtinStatusValues are the status values recognized unless replace_builtin_values is set.
+var builtinStatusValues = map[string]v2.UserTrait_Status_Status{
+	"active":    v2.UserTrait_Status_STATUS_ENABLED,
+	"enabled":   v2.UserTrait_Status_STATUS_ENABLED,
+	"disabled":  v2.UserTrait_Status_STATUS_DISABLED,
+	"inactive":  v2.UserTrait_Status_STATUS_DISABLED,
+	"suspended": v2.UserTrait_Status_STATUS_DISABLED,
+	"locked":    v2.UserTrait_Status_STATUS_DISABLED,
+	"deleted":   v2.UserTrait_Status_STATUS_DELETED,
+}
+
+// accountTypes are the account types that account type values can map to.
+var accountTypes = map[string]v2.UserTrait_AccountType{
+	"human":       v2.UserTrait_ACCOUNT_TYPE_HUMAN,
+	"service":     v2.UserTrait_ACCOUNT_TYPE_SERVICE,
+	"system":      v2.UserTrait_ACCOUNT_TYPE_SYSTEM,
+	"unspecified": v2.UserTrait_ACCOUNT_TYPE_UNSPECIFIED,
+}
+
+// builtinAccountTypeValues are the account type values recognized unless replace_builtin_values is set.
+var builtinAccountTypeValues = map[string]v2.UserTrait_AccountType{
+	"user":    v2.UserTrait_ACCOUNT_TYPE_HUMAN,
+	"human":   v2.UserTrait_ACCOUNT_TYPE_HUMAN,
+	"service": v2.UserTrait_ACCOUNT_TYPE_SERVICE,
+	"system":  v2.UserTrait_ACCOUNT_TYPE_SYSTEM,
+}
+
+// userStatus maps a status value to a user status. ok is false if the value is not mapped and no unmapped_status
+// is configured, in which case the status is unspecified.
+func (m *UserTraitMapping) userStatus(value string) (v2.UserTrait_Status_Status, bool, error) {
+	status, ok, err := lookupTraitValue(value, m.StatusValues, builtinStatusValues, userStatuses, m.ReplaceBuiltinValues)
+	if err != nil || ok {
+		return status, ok, err
+	}
+
+	return unmappedTraitValue("status", value, m.UnmappedStatus, userStatuses, v2.UserTrait_Status_STATUS_UNSPECIFIED)
+}
+
+// accountType maps an account type value to an account type. ok is false if the value is not mapped and no
+// unmapped_account_type is configured, in which case the account type is human.
+func (m *UserTraitMapping) accountType(value string) (v2.UserTrait_AccountType, bool, error) {
+	accountType, ok, err := lookupTraitValue(value, m.AccountTypeValues, builtinAccountTypeValues, accountTypes, m.ReplaceBuiltinValues)
+	if err != nil || ok {
+		return accountType, ok, err
+	}
+
+	return unmappedTraitValue("account type", value, m.UnmappedAccountType, accountTypes, v2.UserTrait_ACCOUNT_TYPE_HUMAN)
+}
+
+// lookupTraitValue looks value up in the configured values, whose targets are keys of targets, and then in the
+// built-in values unless replace is set. Values are matched case-insensitively, preferring an exact match; Parse
+// rejects configured values that only differ in case.
+func lookupTraitValue[T any](
+	value string,
+	configured map[string]string,
+	builtin map[string]T,
+	targets map[string]T,
+	replace bool,
+) (T, bool, error) {
+	var zero T
+
+	keys := sortedKeys(configured)
+	if _, ok := configured[value]; ok {
+		keys = []string{value}
+	}
+
+	for _, k := range keys {
+		if !strings.EqualFold(k, value) {
+			continue
+		}
+		target := configured[k]
+
+		ret, ok := targets[strings.ToLower(target)]
+		if !ok {
+			return zero, false, fmt.Errorf("invalid mapping %q: %q, expected one of %s", k, target, targetNames(targets))
+		}
+		return ret, true, nil
+	}
+
+	if !replace {
+		if ret, ok := builtin[strings.ToLower(value)]; ok {
+			return ret, true, nil
+		}
+	}
+
+	return zero, false, nil
+}
+
+// unmappedTraitValue returns the value to use for an unmapped value: def when unmapped is empty, an error when
+// unmapped is "error", and the target named by unmapped otherwise.
+func unmappedTraitValue[T any](kind string, value string, unmapped string, targets map[string]T, def T) (T, bool, error) {
+	switch strings.ToLower(unmapped) {
+	case "":
+		return def, false, nil
+	case unmappedError:
+		return def, false, fmt.Errorf("unmapped %s value %q", kind, value)
+	}
+
+	ret, ok := targets[strings.ToLower(unmapped)]
+	if !ok {
+		return def, false, fmt.Errorf("invalid unmapped %s %q, expected %s or one of %s", kind, unmapped, unmappedError, targetNames(targets))
+	}
+	return ret, true, nil
+}
+
+// validateTraitValues checks that the configured values map to one of targets and that no two of them only differ
+// in case, which would make the match ambiguous.
+func validateTraitValues[T any](configured map[string]string, targets map[string]T) []error {
+	var errs []error
+	seen := make(map[string]string)
+	for _, k := range sortedKeys(configured) {
+		if prev, ok := seen[strings.ToLower(k)]; ok {
+			errs = append(errs, fmt.Errorf("values %q and %q only differ in case", prev, k))
+		}
+		seen[strings.ToLower(k)] = k
+
+		if _, ok := targets[strings.ToLower(configured[k])]; !ok {
+			errs = append(errs, fmt.Errorf("invalid mapping %q: %q, expected one of %s", k, configured[k], targetNames(targets)))
+		}
+	}
+	return errs
+}
+
+func targetNames[T any](targets map[string]T) string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,112 @@
+package bsql
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserTraitMapping_userStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping UserTraitMapping
+		value   string
+		want    v2.UserTrait_Status_Status
+		wantOk  bool
+		wantErr bool
+	}{
+		{"builtin", UserTraitMapping{}, "Active", v2.UserTrait_Status_STATUS_ENABLED, true, false},
+		{"unmapped", UserTraitMapping{}, "2", v2.UserTrait_Status_STATUS_UNSPECIFIED, false, false},
+		{
+			"configured",
+			UserTraitMapping{StatusValues: map[string]string{"0": "disabled", "1": "enabled", "2": "deleted"}},
+			"2", v2.UserTrait_Status_STATUS_DELETED, true, false,
+		},
+		{
+			"configured extends builtin",
+			UserTraitMapping{StatusValues: map[string]string{"0": "disabled"}},
+			"locked", v2.UserTrait_Status_STATUS_DISABLED, true, false,
+		},
+		{
+			"configured overrides builtin",
+			UserTraitMapping{StatusValues: map[string]string{"locked": "enabled"}},
+			"LOCKED", v2.UserTrait_Status_STATUS_ENABLED, true, false,
+		},
+		{
+			"replace builtin",
+			UserTraitMapping{StatusValues: map[string]string{"A": "enabled"}, ReplaceBuiltinValues: true},
+			"active", v2.UserTrait_Status_STATUS_UNSPECIFIED, false, false,
+		},
+		{
+			"unmapped default",
+			UserTraitMapping{UnmappedStatus: "disabled"},
+			"X", v2.UserTrait_Status_STATUS_DISABLED, true, false,
+		},
+		{"unmapped error", UserTraitMapping{UnmappedStatus: "error"}, "X", 0, false, true},
+		{"invalid unmapped", UserTraitMapping{UnmappedStatus: "gone"}, "X", 0, false, true},
+		{"invalid target", UserTraitMapping{StatusValues: map[string]string{"X": "gone"}}, "X", 0, false, true},
+		{
+			"exact match first",
+			UserTraitMapping{StatusValues: map[string]string{"a": "disabled", "A": "enabled"}},
+			"A", v2.UserTrait_Status_STATUS_ENABLED, true, false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := tt.mapping.userStatus(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantOk, ok)
+		})
+	}
+}
+
+func TestUserTraitMapping_accountType(t *testing.T) {
+	m := UserTraitMapping{AccountTypeValues: map[string]string{"A": "human", "T": "service"}}
+
+	got, ok, err := m.accountType("t")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, v2.UserTrait_ACCOUNT_TYPE_SERVICE, got)
+
+	got, ok, err = m.accountType("system")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, v2.UserTrait_ACCOUNT_TYPE_SYSTEM, got)
+
+	got, ok, err = m.accountType("Z")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, v2.UserTrait_ACCOUNT_TYPE_HUMAN, got)
+
+	m.UnmappedAccountType = "error"
+	_, _, err = m.accountType("Z")
+	require.ErrorContains(t, err, `unmapped account type value "Z"`)
+}
+
+func TestParse_TraitValueCollisions(t *testing.T) {
+	_, err := Parse([]byte(`
+resource_types:
+  user:
+    name: User
+    list:
+      query: SELECT id, status FROM users
+      map:
+        id: ".id"
+        traits:
+          user:
+            status: ".status"
+            status_values:
+              a: enabled
+              A: disabled
+            account_type_values:
+              t: robot
+`))
+	require.ErrorContains(t, err, `resource_types.user.list.map.traits.user.status_values (line 13): values "A" and "a" only differ in case`)
+	require.ErrorContains(t, err, `invalid mapping "t": "robot"`)
+}
@@ -5,13 +5,36 @@ import "github.com/conductorone/baton-sdk/pkg/field"
 var (
 	ConfigPathField = field.StringField(
 		"config-path",
-		field.WithRequired(true),
 		field.WithDescription("The file path to the baton-sql config to use"),
 	)
 
+	ConfigYAMLField = field.StringField(
+		"config-yaml",
+		field.WithDescription("The baton-sql config to use, as YAML. Used instead of config-path"),
+	)
+
+	ConfigYAMLBase64Field = field.StringField(
+		"config-yaml-base64",
+		field.WithDescription("The baton-sql config to use, as base64 encoded YAML. Used instead of config-path"),
+	)
+
+	AgeIdentityFileField = field.StringField(
+		"age-identity-file",
+		field.WithDescription("The file path to an age identity used to decrypt ENC[...] values in the config"),
+	)
+
 	// ConfigurationFields defines the external configuration required for the connector to run.
 	ConfigurationFields = []field.SchemaField{
 		ConfigPathField,
+		ConfigYAMLField,
+		ConfigYAMLBase64Field,
+		AgeIdentityFileField,
+	}
+
+	// ConfigurationConstraints requires exactly one source for the baton-sql config.
+	ConfigurationConstraints = []field.SchemaFieldRelationship{
+		field.FieldsMutuallyExclusive(ConfigPathField, ConfigYAMLField, ConfigYAMLBase64Field),
+		field.FieldsAtLeastOneUsed(ConfigPathField, ConfigYAMLField, ConfigYAMLBase64Field),
 	}
-	ConfigurationSchema = field.NewConfiguration(ConfigurationFields)
+	ConfigurationSchema = field.NewConfiguration(ConfigurationFields, ConfigurationConstraints...)
 )
@@ -3,7 +3,6 @@ package config
 import (
 	"testing"
 
-	"github.com/conductorone/baton-sdk/pkg/field"
 	"github.com/conductorone/baton-sdk/pkg/test"
 	"github.com/conductorone/baton-sdk/pkg/ustrings"
 )
@@ -11,7 +10,7 @@ import (
 func TestConfigs(t *testing.T) {
 	test.ExerciseTestCasesFromExpressions(
 		t,
-		field.NewConfiguration(ConfigurationFields),
+		ConfigurationSchema,
 		nil,
 		ustrings.ParseFlags,
 		[]test.TestCaseFromExpression{
@@ -25,6 +24,26 @@ func TestConfigs(t *testing.T) {
 				true,
 				"all",
 			},
+			{
+				"--config-yaml app_name:Test",
+				true,
+				"inline yaml",
+			},
+			{
+				"--config-yaml-base64 YXBwX25hbWU6IFRlc3QK",
+				true,
+				"inline base64 yaml",
+			},
+			{
+				"--config-path ./examples/wordpress.yml --config-yaml app_name:Test",
+				false,
+				"path and inline yaml",
+			},
+			{
+				"--config-yaml app_name:Test --config-yaml-base64 YXBwX25hbWU6IFRlc3QK",
+				false,
+				"both inline sources",
+			},
 		},
 	)
 }
@@ -4,11 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"io"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/conductorone/baton-sql/pkg/bcel"
 	"github.com/conductorone/baton-sql/pkg/bsql"
@@ -16,16 +19,71 @@ import (
 )
 
 type Connector struct {
-	config   *bsql.Config
-	db       *sql.DB
-	dbEngine database.DbEngine
-	celEnv   *bcel.Env
+	config  *bsql.Config
+	conns   bsql.DBConnections
+	state   *bsql.SyncState
+	events  *bsql.EventFeed
+	lookups *bsql.Lookups
+	celEnv  *bcel.Env
 }
 
 func (c *Connector) Close() error {
 	var errs error
-	if c.db != nil {
-		err := c.db.Close()
+	for _, conn := range c.conns {
+		errs = errors.Join(errs, closeConnection(conn))
+	}
+	return errs
+}
+
+// startSync ends the snapshots left over from an earlier sync, so that the new sync starts fresh ones, drops
+// the incremental watermarks of an earlier sync that did not complete, and loads the lookup tables for the sync.
+func (c *Connector) startSync(ctx context.Context) error {
+	if c.state != nil {
+		c.state.Discard()
+	}
+	err := c.closeSnapshots()
+	if err != nil {
+		return err
+	}
+	return c.lookups.Load(ctx)
+}
+
+// endSync ends the snapshots of the sync, so that no transaction is held open between syncs, and writes the
+// incremental watermarks of the sync.
+func (c *Connector) endSync() error {
+	var errs error
+	if c.state != nil {
+		errs = c.state.Commit()
+	}
+	return errors.Join(errs, c.closeSnapshots())
+}
+
+func (c *Connector) closeSnapshots() error {
+	var errs error
+	for _, conn := range c.conns {
+		if conn.Snapshot != nil {
+			errs = errors.Join(errs, conn.Snapshot.Close())
+		}
+	}
+	return errs
+}
+
+func closeConnection(conn *bsql.DBConnection) error {
+	var errs error
+	if conn.Snapshot != nil {
+		err := conn.Snapshot.Close()
+		if err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	if conn.DB != nil {
+		err := conn.DB.Close()
+		if err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	if conn.WriteDB != nil && conn.WriteDB != conn.DB {
+		err := conn.WriteDB.Close()
 		if err != nil {
 			errs = errors.Join(errs, err)
 		}
@@ -35,7 +93,7 @@ func (c *Connector) Close() error {
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (c *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
-	syncers, err := c.config.GetSQLSyncers(ctx, c.db, c.dbEngine, c.celEnv)
+	syncers, err := c.config.GetSQLSyncers(ctx, c.conns, c.celEnv, c.state)
 	if err != nil {
 		return nil
 	}
@@ -49,6 +107,24 @@ func (c *Connector) Asset(ctx context.Context, asset *v2.AssetRef) (string, io.R
 	return "", nil, nil
 }
 
+// ListEvents returns events from the configured events query. Without an events section the feed is empty.
+func (c *Connector) ListEvents(
+	ctx context.Context,
+	earliestEvent *timestamppb.Timestamp,
+	pToken *pagination.StreamToken,
+) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	if c.events == nil {
+		return nil, &pagination.StreamState{Cursor: pToken.Cursor}, nil, nil
+	}
+
+	err := c.lookups.EnsureLoaded(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return c.events.ListEvents(ctx, earliestEvent, pToken)
+}
+
 // Metadata returns metadata about the connector.
 func (c *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
 	md := &v2.ConnectorMetadata{
@@ -69,12 +145,34 @@ func (c *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error)
 // Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
 // to be sure that they are valid.
 func (c *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
+	_, provisioningConns := c.config.UsedConnections()
+	for name := range provisioningConns {
+		conn := c.conns[name]
+		readOnly, err := database.IsReadOnly(ctx, conn.WriteDB, conn.Engine)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if the write connection %s is read-only: %w", connectionLabel(name), err)
+		}
+		if readOnly {
+			return nil, fmt.Errorf("the write connection %s is read-only, provisioning requires a writable connection", connectionLabel(name))
+		}
+	}
+
 	return nil, nil
 }
 
 // New returns a new instance of the connector.
-func New(ctx context.Context, configFilePath string) (*Connector, error) {
-	c, err := bsql.LoadConfigFromFile(configFilePath)
+func New(ctx context.Context, configFilePath string, opts ...bsql.Option) (*Connector, error) {
+	c, err := bsql.LoadConfigFromFile(configFilePath, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConnector(ctx, c)
+}
+
+// NewFromYAML returns a new instance of the connector for a config passed inline rather than as a file.
+func NewFromYAML(ctx context.Context, data []byte, opts ...bsql.Option) (*Connector, error) {
+	c, err := bsql.Parse(data, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -83,20 +181,159 @@ func New(ctx context.Context, configFilePath string) (*Connector, error) {
 }
 
 func newConnector(ctx context.Context, c *bsql.Config) (*Connector, error) {
-	db, dbEngine, err := database.Connect(ctx, c.Connect.DSN, c.Connect.User, c.Connect.Password)
+	var state *bsql.SyncState
+	if c.UsesIncrementalSync() {
+		var err error
+		state, err = bsql.LoadSyncState(c.StatePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	conns, err := connectAll(ctx, c)
 	if err != nil {
 		return nil, err
 	}
 
-	celEnv, err := bcel.NewEnv(ctx)
+	ret := &Connector{
+		config:  c,
+		conns:   conns,
+		state:   state,
+		lookups: c.GetLookups(conns),
+	}
+
+	ret.celEnv, err = c.NewEnv(ctx, bcel.WithLookupTables(ret.lookups))
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("invalid CEL functions in config:\n%w", err), ret.Close())
+	}
+
+	err = c.CompileExpressions(ret.celEnv)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("invalid CEL expressions in config:\n%w", err), ret.Close())
+	}
+	ret.events = c.GetEventFeed(conns, ret.celEnv)
+
+	return ret, nil
+}
+
+// connectAll opens the default connection and every named connection.
+// The default connection is only required when a query does not name a connection.
+func connectAll(ctx context.Context, c *bsql.Config) (bsql.DBConnections, error) {
+	syncConns, provisioningConns := c.UsedConnections()
+
+	ret := make(bsql.DBConnections)
+	closeAll := func() error {
+		var errs error
+		for _, conn := range ret {
+			errs = errors.Join(errs, closeConnection(conn))
+		}
+		return errs
+	}
+
+	if c.Connect.IsSet() || syncConns[""] || provisioningConns[""] {
+		conn, err := connect(ctx, c.Connect, c.DecryptConnectionValue)
+		if err != nil {
+			return nil, err
+		}
+		ret[""] = conn
+	}
+
+	for name, dbConfig := range c.Connect.Connections {
+		if name == "" {
+			return nil, errors.Join(errors.New("connect: connection names must not be empty"), closeAll())
+		}
+		if dbConfig == nil {
+			return nil, errors.Join(fmt.Errorf("connect: connection %s has no configuration", name), closeAll())
+		}
+		if len(dbConfig.Connections) > 0 {
+			return nil, errors.Join(fmt.Errorf("connect: connection %s cannot define nested connections", name), closeAll())
+		}
+
+		conn, err := connect(ctx, *dbConfig, c.DecryptConnectionValue)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("connect: connection %s: %w", name, err), closeAll())
+		}
+		ret[name] = conn
+	}
+
+	for _, used := range []map[string]bool{syncConns, provisioningConns} {
+		for name := range used {
+			if _, ok := ret[name]; !ok {
+				return nil, errors.Join(fmt.Errorf("connect: connection %s is not defined", connectionLabel(name)), closeAll())
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func connectionLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// connect opens the read and write connections, sharing a single connection when no split is configured.
+func connect(ctx context.Context, c bsql.DatabaseConfig, decrypt database.Decrypter) (*bsql.DBConnection, error) {
+	readConfig, err := c.ReadConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	var isolation sql.IsolationLevel
+	if c.Snapshot != nil && c.Snapshot.Enabled {
+		isolation, err = database.ParseIsolationLevel(c.Snapshot.IsolationLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var pool *database.PoolOptions
+	if c.Pool != nil {
+		pool = &database.PoolOptions{
+			MaxOpenConns:    c.Pool.MaxOpenConns,
+			MaxIdleConns:    c.Pool.MaxIdleConns,
+			ConnMaxLifetime: c.Pool.ConnMaxLifetime,
+			ConnMaxIdleTime: c.Pool.ConnMaxIdleTime,
+		}
+	}
+
+	db, dbEngine, err := database.Connect(ctx, readConfig.DSN, readConfig.User, readConfig.Password, pool, decrypt)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Connector{
-		config:   c,
-		db:       db,
-		dbEngine: dbEngine,
-		celEnv:   celEnv,
-	}, nil
+	ret := &bsql.DBConnection{
+		DB:      db,
+		WriteDB: db,
+		Engine:  dbEngine,
+	}
+
+	if c.Read != nil || c.Write != nil {
+		writeConfig, err := c.WriteConnection()
+		if err != nil {
+			return nil, errors.Join(err, db.Close())
+		}
+
+		writeDB, writeEngine, err := database.Connect(ctx, writeConfig.DSN, writeConfig.User, writeConfig.Password, pool, decrypt)
+		if err != nil {
+			return nil, errors.Join(err, db.Close())
+		}
+
+		if writeEngine != dbEngine {
+			return nil, errors.Join(
+				errors.New("connect: read and write connections must use the same database engine"),
+				db.Close(),
+				writeDB.Close(),
+			)
+		}
+		ret.WriteDB = writeDB
+	}
+
+	if c.Snapshot != nil && c.Snapshot.Enabled {
+		ret.Snapshot = database.NewSnapshot(db, dbEngine, database.SnapshotOptions(dbEngine, isolation), c.Snapshot.MaxAge)
+	}
+
+	return ret, nil
 }
@@ -0,0 +1,72 @@
+package connector
+
+import (
+	"context"
+	"errors"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/types"
+)
+
+// syncServer tells the connector when a sync starts and ends, which the SDK does not do for connector builders.
+// The SDK syncer lists the resource types as the first step of every sync and calls Cleanup once it is complete.
+// Provisioning requests can arrive outside a sync, so they load the lookup tables if no sync has.
+type syncServer struct {
+	types.ConnectorServer
+	connector *Connector
+}
+
+// NewServer returns the connector server for c.
+func NewServer(ctx context.Context, c *Connector) (types.ConnectorServer, error) {
+	srv, err := connectorbuilder.NewConnector(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syncServer{
+		ConnectorServer: srv,
+		connector:       c,
+	}, nil
+}
+
+func (s *syncServer) ListResourceTypes(
+	ctx context.Context,
+	request *v2.ResourceTypesServiceListResourceTypesRequest,
+) (*v2.ResourceTypesServiceListResourceTypesResponse, error) {
+	if request.GetPageToken() == "" {
+		err := s.connector.startSync(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.ConnectorServer.ListResourceTypes(ctx, request)
+}
+
+// Grant loads the lookup tables first if no sync has loaded them, as provisioning expressions may use them.
+func (s *syncServer) Grant(ctx context.Context, request *v2.GrantManagerServiceGrantRequest) (*v2.GrantManagerServiceGrantResponse, error) {
+	err := s.connector.lookups.EnsureLoaded(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ConnectorServer.Grant(ctx, request)
+}
+
+// Revoke loads the lookup tables first if no sync has loaded them, as provisioning expressions may use them.
+func (s *syncServer) Revoke(ctx context.Context, request *v2.GrantManagerServiceRevokeRequest) (*v2.GrantManagerServiceRevokeResponse, error) {
+	err := s.connector.lookups.EnsureLoaded(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ConnectorServer.Revoke(ctx, request)
+}
+
+func (s *syncServer) Cleanup(ctx context.Context, request *v2.ConnectorServiceCleanupRequest) (*v2.ConnectorServiceCleanupResponse, error) {
+	endErr := s.connector.endSync()
+
+	resp, err := s.ConnectorServer.Cleanup(ctx, request)
+	return resp, errors.Join(endErr, err)
+}
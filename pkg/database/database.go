@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/conductorone/baton-sql/pkg/database/mysql"
 	"github.com/conductorone/baton-sql/pkg/database/oracle"
@@ -16,6 +17,15 @@ import (
 
 var DSNREnvRegex = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
 
+// FileRefRegex matches a ${file:/path} reference to a secret file.
+var FileRefRegex = regexp.MustCompile(`\$\{file:([^}]+)\}`)
+
+// EncryptedRegex matches a value of the form ENC[...], the base64 encoded age file of a secret.
+var EncryptedRegex = regexp.MustCompile(`^ENC\[([A-Za-z0-9+/=\s]+)\]$`)
+
+// Decrypter returns the plaintext of an ENC[...] value.
+type Decrypter func(value string) (string, error)
+
 type DbEngine uint8
 
 const (
@@ -47,8 +57,80 @@ func updateFromEnv(ctx context.Context, dsn string) (string, error) {
 	return result, nil
 }
 
-func Connect(ctx context.Context, dsn string, user string, password string) (*sql.DB, DbEngine, error) {
-	populatedDSN, err := updateFromEnv(ctx, dsn)
+// resolveFileRefs replaces ${file:/path} references in s with the contents of the file, without a trailing
+// newline. In a DSN the contents are percent-encoded, so that a password may hold characters such as @ or /.
+// It runs after updateFromEnv, so ${VAR} inside a secret is never expanded.
+func resolveFileRefs(s string, inURL bool) (string, error) {
+	var err error
+
+	result := FileRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		path := FileRefRegex.FindStringSubmatch(ref)[1]
+		b, readErr := os.ReadFile(path)
+		if readErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to read %s: %w", ref, readErr))
+			return ref
+		}
+
+		value := strings.TrimRight(string(b), "\r\n")
+		if inURL {
+			// QueryEscape leaves only unreserved characters, and %20 is a space in every part of the URL.
+			value = strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+// expandConnectionValue expands ${VAR} references to environment variables, and then ${file:/path} references.
+// An ENC[...] value is decrypted instead and used as it is: its plaintext is a whole DSN, user or password, so it is
+// neither expanded nor escaped.
+func expandConnectionValue(ctx context.Context, s string, inURL bool, decrypt Decrypter) (string, error) {
+	if EncryptedRegex.MatchString(s) {
+		if decrypt == nil {
+			return "", errors.New("encrypted value found but no decrypter was provided")
+		}
+		return decrypt(s)
+	}
+
+	s, err := updateFromEnv(ctx, s)
+	if err != nil {
+		return "", err
+	}
+	return resolveFileRefs(s, inURL)
+}
+
+// EngineFromDSN returns the engine a DSN connects to, judged by its scheme. It returns Unknown if the scheme is
+// not supported or comes from a variable.
+func EngineFromDSN(dsn string) DbEngine {
+	scheme, _, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return Unknown
+	}
+	return engineForScheme(strings.ToLower(scheme))
+}
+
+func engineForScheme(scheme string) DbEngine {
+	switch scheme {
+	case "mysql":
+		return MySQL
+	case "oracle":
+		return Oracle
+	case "sqlserver":
+		return MSSQL
+	default:
+		return Unknown
+	}
+}
+
+// Connect opens a connection pool for the DSN and applies the pool options to it.
+// The DSN, user and password may reference environment variables as ${VAR} and secret files as ${file:/path}, or
+// be ENC[...] values, which are decrypted with decrypt.
+func Connect(ctx context.Context, dsn string, user string, password string, pool *PoolOptions, decrypt Decrypter) (*sql.DB, DbEngine, error) {
+	populatedDSN, err := expandConnectionValue(ctx, dsn, true, decrypt)
 	if err != nil {
 		return nil, Unknown, err
 	}
@@ -63,12 +145,12 @@ func Connect(ctx context.Context, dsn string, user string, password string) (*sq
 			return nil, Unknown, errors.New("user and password must be set in DSN or in the configuration")
 		}
 
-		populatedUser, err := updateFromEnv(ctx, user)
+		populatedUser, err := expandConnectionValue(ctx, user, false, decrypt)
 		if err != nil {
 			return nil, Unknown, err
 		}
 
-		populatedPassword, err := updateFromEnv(ctx, password)
+		populatedPassword, err := expandConnectionValue(ctx, password, false, decrypt)
 		if err != nil {
 			return nil, Unknown, err
 		}
@@ -76,28 +158,37 @@ func Connect(ctx context.Context, dsn string, user string, password string) (*sq
 		parsedDsn.User = url.UserPassword(populatedUser, populatedPassword)
 	}
 
-	switch parsedDsn.Scheme {
-	case "mysql":
-		db, err := mysql.Connect(ctx, parsedDsn.String())
-		if err != nil {
-			return nil, Unknown, err
-		}
-		return db, MySQL, nil
+	var db *sql.DB
+	engine := engineForScheme(parsedDsn.Scheme)
+	switch engine {
+	case MySQL:
+		db, err = mysql.Connect(ctx, parsedDsn.String())
+	case Oracle:
+		db, err = oracle.Connect(ctx, parsedDsn.String())
+	case MSSQL:
+		db, err = sqlserver.Connect(ctx, parsedDsn.String())
+	default:
+		return nil, Unknown, fmt.Errorf("unsupported database scheme: %s", parsedDsn.Scheme)
+	}
+	if err != nil {
+		return nil, Unknown, err
+	}
 
-	case "oracle":
-		db, err := oracle.Connect(ctx, parsedDsn.String())
-		if err != nil {
-			return nil, Unknown, err
-		}
-		return db, Oracle, nil
+	pool.apply(db)
 
-	case "sqlserver":
-		db, err := sqlserver.Connect(ctx, parsedDsn.String())
-		if err != nil {
-			return nil, Unknown, err
-		}
-		return db, MSSQL, nil
+	return db, engine, nil
+}
+
+// IsReadOnly reports whether the database behind db rejects writes.
+func IsReadOnly(ctx context.Context, db *sql.DB, engine DbEngine) (bool, error) {
+	switch engine {
+	case MySQL:
+		return mysql.IsReadOnly(ctx, db)
+	case MSSQL:
+		return sqlserver.IsReadOnly(ctx, db)
+	case Oracle:
+		return oracle.IsReadOnly(ctx, db)
 	default:
-		return nil, Unknown, fmt.Errorf("unsupported database scheme: %s", parsedDsn.Scheme)
+		return false, fmt.Errorf("read-only check is not supported for database engine %d", engine)
 	}
 }
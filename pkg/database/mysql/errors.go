@@ -0,0 +1,27 @@
+package mysql
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	errLockWaitTimeout = 1205
+	errLockDeadlock    = 1213
+)
+
+// IsRetryableError reports whether err is a MySQL deadlock or lock wait timeout.
+func IsRetryableError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+
+	switch mysqlErr.Number {
+	case errLockWaitTimeout, errLockDeadlock:
+		return true
+	default:
+		return false
+	}
+}
@@ -6,17 +6,10 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
-	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
-const (
-	MaxIdleConns    = 10
-	MaxOpenConns    = 10
-	MaxConnLifetime = 5 * time.Minute
-)
-
 func convertURItoDSN(uri string) (string, error) {
 	parsedURI, err := url.Parse(uri)
 	if err != nil {
@@ -61,10 +54,5 @@ func Connect(ctx context.Context, dsn string) (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	db.SetMaxOpenConns(MaxOpenConns)
-	db.SetMaxIdleConns(MaxIdleConns)
-	db.SetConnMaxLifetime(MaxConnLifetime)
-
 	return db, nil
 }
@@ -0,0 +1,151 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const clearVarsTimeout = 5 * time.Second
+
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// CallProcedure calls the stored procedure name with positional arguments in the order given.
+// The driver has no OUT parameter support, so OUT and INOUT parameters go through session variables
+// that are read back after the call. ex must therefore stay on a single connection. The variables are
+// cleared afterwards, so values do not leak to later users of a pooled connection.
+func CallProcedure(ctx context.Context, ex executor, name string, args []sql.NamedArg) (retErr error) {
+	placeholders := make([]string, 0, len(args))
+	var callArgs []any
+	var outVars []string
+	var outDests []any
+
+	defer func() {
+		if len(outVars) == 0 {
+			return
+		}
+		retErr = errors.Join(retErr, clearVars(ctx, ex, outVars))
+	}()
+
+	for _, arg := range args {
+		out, ok := arg.Value.(sql.Out)
+		if !ok {
+			placeholders = append(placeholders, "?")
+			callArgs = append(callArgs, arg.Value)
+			continue
+		}
+
+		v := "@baton_" + arg.Name
+		outVars = append(outVars, v)
+		if out.In {
+			_, err := ex.ExecContext(ctx, fmt.Sprintf("SET %s = ?", v), outValue(out.Dest))
+			if err != nil {
+				return err
+			}
+		}
+		placeholders = append(placeholders, v)
+		outDests = append(outDests, out.Dest)
+	}
+
+	_, err := ex.ExecContext(ctx, fmt.Sprintf("CALL %s(%s)", name, strings.Join(placeholders, ", ")), callArgs...)
+	if err != nil {
+		return err
+	}
+
+	if len(outVars) == 0 {
+		return nil
+	}
+
+	scanArgs := make([]any, len(outDests))
+	for i, dest := range outDests {
+		scanArgs[i], err = nullDest(dest)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ex.QueryRowContext(ctx, "SELECT "+strings.Join(outVars, ", ")).Scan(scanArgs...)
+	if err != nil {
+		return err
+	}
+
+	for i, dest := range outDests {
+		assignNull(dest, scanArgs[i])
+	}
+
+	return nil
+}
+
+// clearVars sets the session variables back to NULL. It runs even if ctx is done, as the connection may
+// go back to the pool.
+func clearVars(ctx context.Context, ex executor, vars []string) error {
+	assignments := make([]string, len(vars))
+	for i, v := range vars {
+		assignments[i] = v + " = NULL"
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), clearVarsTimeout)
+	defer cancel()
+
+	_, err := ex.ExecContext(ctx, "SET "+strings.Join(assignments, ", "))
+	if err != nil {
+		return fmt.Errorf("failed to clear procedure session variables: %w", err)
+	}
+	return nil
+}
+
+func outValue(dest any) any {
+	switch d := dest.(type) {
+	case *string:
+		return *d
+	case *int64:
+		return *d
+	case *float64:
+		return *d
+	case *bool:
+		return *d
+	case *time.Time:
+		return *d
+	default:
+		return nil
+	}
+}
+
+// nullDest returns a nullable scan destination for dest, as session variables are NULL until set.
+func nullDest(dest any) (any, error) {
+	switch dest.(type) {
+	case *string:
+		return &sql.NullString{}, nil
+	case *int64:
+		return &sql.NullInt64{}, nil
+	case *float64:
+		return &sql.NullFloat64{}, nil
+	case *bool:
+		return &sql.NullBool{}, nil
+	case *time.Time:
+		return &sql.NullTime{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported OUT parameter destination %T", dest)
+	}
+}
+
+func assignNull(dest any, src any) {
+	switch d := dest.(type) {
+	case *string:
+		*d = src.(*sql.NullString).String
+	case *int64:
+		*d = src.(*sql.NullInt64).Int64
+	case *float64:
+		*d = src.(*sql.NullFloat64).Float64
+	case *bool:
+		*d = src.(*sql.NullBool).Bool
+	case *time.Time:
+		*d = src.(*sql.NullTime).Time
+	}
+}
@@ -0,0 +1,50 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type recordingExecutor struct {
+	statements []string
+}
+
+func (r *recordingExecutor) ExecContext(_ context.Context, query string, _ ...any) (sql.Result, error) {
+	r.statements = append(r.statements, query)
+	if strings.HasPrefix(query, "CALL") {
+		return nil, errors.New("procedure failed")
+	}
+	return nil, nil
+}
+
+func (r *recordingExecutor) QueryRowContext(context.Context, string, ...any) *sql.Row {
+	panic("unexpected query")
+}
+
+func TestCallProcedure_clearsVars(t *testing.T) {
+	ex := &recordingExecutor{}
+	var status string
+	var count int64
+
+	err := CallProcedure(context.Background(), ex, "grant_role", []sql.NamedArg{
+		sql.Named("user_id", "42"),
+		sql.Named("status", sql.Out{Dest: &status}),
+		sql.Named("count", sql.Out{Dest: &count, In: true}),
+	})
+	if err == nil {
+		t.Fatal("expected the procedure error")
+	}
+
+	want := []string{
+		"SET @baton_count = ?",
+		"CALL grant_role(?, @baton_status, @baton_count)",
+		"SET @baton_status = NULL, @baton_count = NULL",
+	}
+	if !reflect.DeepEqual(ex.statements, want) {
+		t.Errorf("statements = %v, want %v", ex.statements, want)
+	}
+}
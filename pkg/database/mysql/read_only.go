@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// errUnknownSystemVariable is returned by servers without super_read_only, such as MariaDB and MySQL before 5.7.8.
+const errUnknownSystemVariable = 1193
+
+// IsReadOnly reports whether the server rejects writes because read_only or super_read_only is enabled.
+func IsReadOnly(ctx context.Context, db *sql.DB) (bool, error) {
+	var readOnly bool
+	err := db.QueryRowContext(ctx, "SELECT @@read_only").Scan(&readOnly)
+	if err != nil {
+		return false, err
+	}
+	if readOnly {
+		return true, nil
+	}
+
+	// super_read_only also rejects writes from users with SUPER, which read_only lets through.
+	var superReadOnly bool
+	err = db.QueryRowContext(ctx, "SELECT @@super_read_only").Scan(&superReadOnly)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errUnknownSystemVariable {
+			return false, nil
+		}
+		return false, err
+	}
+	return superReadOnly, nil
+}
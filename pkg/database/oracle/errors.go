@@ -0,0 +1,28 @@
+package oracle
+
+import (
+	"errors"
+
+	"github.com/sijms/go-ora/v2/network"
+)
+
+const (
+	errDeadlockDetected   = 60   // ORA-00060
+	errCannotSerialize    = 8177 // ORA-08177
+	errResourceBusyNoWait = 54   // ORA-00054
+)
+
+// IsRetryableError reports whether err is an Oracle deadlock, serialization failure, or busy resource error.
+func IsRetryableError(err error) bool {
+	var oraErr *network.OracleError
+	if !errors.As(err, &oraErr) {
+		return false
+	}
+
+	switch oraErr.ErrCode {
+	case errDeadlockDetected, errCannotSerialize, errResourceBusyNoWait:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,28 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// CallProcedure calls the stored procedure name from an anonymous PL/SQL block, passing each argument
+// by name. OUT parameters are passed as sql.Out values and filled in by the driver.
+func CallProcedure(ctx context.Context, ex executor, name string, args []sql.NamedArg) error {
+	binds := make([]string, 0, len(args))
+	callArgs := make([]any, 0, len(args))
+	for _, arg := range args {
+		binds = append(binds, fmt.Sprintf("%s => :%s", arg.Name, arg.Name))
+		callArgs = append(callArgs, arg)
+	}
+
+	stmt := fmt.Sprintf("BEGIN %s(%s); END;", name, strings.Join(binds, ", "))
+
+	_, err := ex.ExecContext(ctx, stmt, callArgs...)
+	return err
+}
@@ -0,0 +1,42 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/sijms/go-ora/v2/network"
+)
+
+// errTableNotFound is ORA-00942, which Oracle also returns when the user may not read V$DATABASE.
+const errTableNotFound = 942
+
+// IsReadOnly reports whether the database only accepts reads: a physical standby, or a database that is mounted
+// or open read only. Reading the open mode needs SELECT on V$DATABASE. Without it only the database role is checked.
+func IsReadOnly(ctx context.Context, db *sql.DB) (bool, error) {
+	var role string
+	err := db.QueryRowContext(ctx, "SELECT SYS_CONTEXT('USERENV', 'DATABASE_ROLE') FROM DUAL").Scan(&role)
+	if err != nil {
+		return false, err
+	}
+	if role == "PHYSICAL STANDBY" {
+		return true, nil
+	}
+
+	var openMode string
+	err = db.QueryRowContext(ctx, "SELECT OPEN_MODE FROM V$DATABASE").Scan(&openMode)
+	if err != nil {
+		var oraErr *network.OracleError
+		if errors.As(err, &oraErr) && oraErr.ErrCode == errTableNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return isReadOnlyOpenMode(openMode), nil
+}
+
+// isReadOnlyOpenMode reports whether an open mode, such as READ WRITE, READ ONLY WITH APPLY or MOUNTED, rejects writes.
+func isReadOnlyOpenMode(openMode string) bool {
+	return strings.TrimSpace(openMode) != "READ WRITE"
+}
@@ -0,0 +1,22 @@
+package oracle
+
+import "testing"
+
+func Test_isReadOnlyOpenMode(t *testing.T) {
+	tests := []struct {
+		openMode string
+		want     bool
+	}{
+		{"READ WRITE", false},
+		{"READ ONLY", true},
+		{"READ ONLY WITH APPLY", true},
+		{"MOUNTED", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.openMode, func(t *testing.T) {
+			if got := isReadOnlyOpenMode(tt.openMode); got != tt.want {
+				t.Errorf("isReadOnlyOpenMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// BeginTx starts a transaction and applies the requested isolation level.
+// The go-ora driver only accepts default transaction options, so the isolation level is set with
+// SET TRANSACTION as the first statement of the transaction instead.
+// Read-only transactions always see the database as of the start of the transaction, so an isolation
+// level cannot be combined with ReadOnly.
+func BeginTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions) (*sql.Tx, error) {
+	var stmt string
+	if opts != nil {
+		switch opts.Isolation {
+		case sql.LevelDefault:
+		case sql.LevelReadCommitted:
+			stmt = "SET TRANSACTION ISOLATION LEVEL READ COMMITTED"
+		case sql.LevelSerializable:
+			stmt = "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE"
+		default:
+			return nil, fmt.Errorf("oracle does not support isolation level %s", opts.Isolation)
+		}
+
+		if opts.ReadOnly {
+			if stmt != "" {
+				return nil, errors.New("oracle read-only transactions cannot set an isolation level")
+			}
+			stmt = "SET TRANSACTION READ ONLY"
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt != "" {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return nil, errors.Join(err, tx.Rollback())
+		}
+	}
+
+	return tx, nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	DefaultMaxOpenConns    = 10
+	DefaultMaxIdleConns    = 10
+	DefaultConnMaxLifetime = 5 * time.Minute
+)
+
+// PoolOptions configures the connection pool of a database handle. Zero values use the defaults.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+func (p *PoolOptions) apply(db *sql.DB) {
+	opts := PoolOptions{}
+	if p != nil {
+		opts = *p
+	}
+
+	if opts.MaxOpenConns == 0 {
+		opts.MaxOpenConns = DefaultMaxOpenConns
+	}
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if opts.ConnMaxLifetime == 0 {
+		opts.ConnMaxLifetime = DefaultConnMaxLifetime
+	}
+
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	if opts.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+}
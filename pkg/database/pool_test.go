@@ -0,0 +1,32 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestPoolOptions_apply(t *testing.T) {
+	tests := []struct {
+		name        string
+		pool        *PoolOptions
+		wantMaxOpen int
+	}{
+		{"Defaults without options", nil, DefaultMaxOpenConns},
+		{"Defaults for zero values", &PoolOptions{}, DefaultMaxOpenConns},
+		{"Configured max open connections", &PoolOptions{MaxOpenConns: 25}, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/dbname")
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer db.Close()
+
+			tt.pool.apply(db)
+			if got := db.Stats().MaxOpenConnections; got != tt.wantMaxOpen {
+				t.Errorf("MaxOpenConnections = %v, want %v", got, tt.wantMaxOpen)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/conductorone/baton-sql/pkg/database/mysql"
+	"github.com/conductorone/baton-sql/pkg/database/oracle"
+	"github.com/conductorone/baton-sql/pkg/database/sqlserver"
+)
+
+// Types of OUT parameter values.
+const (
+	ParamTypeString = "string"
+	ParamTypeInt    = "int"
+	ParamTypeFloat  = "float"
+	ParamTypeBool   = "bool"
+	ParamTypeTime   = "time"
+)
+
+var (
+	procedureNameRegex  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$#]*(\.[A-Za-z_][A-Za-z0-9_$#]*){0,2}$`)
+	procedureParamRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Executor runs statements on a database, transaction or connection.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ProcedureParam is a parameter of a stored procedure call.
+type ProcedureParam struct {
+	// Name is the name of the parameter as declared by the procedure.
+	Name string
+
+	// Value is passed to IN and INOUT parameters.
+	Value any
+
+	// In and Out set the direction of the parameter. A parameter that is both is INOUT.
+	In  bool
+	Out bool
+
+	// Type is the type of the value read back from OUT parameters. Defaults to string.
+	Type string
+}
+
+// ProcedureResult holds the values returned by a stored procedure call.
+type ProcedureResult struct {
+	// Out maps OUT parameter names to their values.
+	Out map[string]any
+
+	// ReturnStatus is the procedure's return code. Only SQL Server procedures have one.
+	ReturnStatus *int64
+}
+
+// CallProcedure calls the stored procedure name with params using the calling convention of the engine.
+// MySQL only supports OUT parameters through session variables, so ex must be a transaction or a single
+// connection for OUT values to be read back.
+func CallProcedure(ctx context.Context, ex Executor, engine DbEngine, name string, params []ProcedureParam) (*ProcedureResult, error) {
+	if !procedureNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid procedure name %q", name)
+	}
+
+	args := make([]sql.NamedArg, 0, len(params))
+	for _, p := range params {
+		if !procedureParamRegex.MatchString(p.Name) {
+			return nil, fmt.Errorf("invalid parameter name %q for procedure %s", p.Name, name)
+		}
+
+		if !p.Out {
+			args = append(args, sql.Named(p.Name, p.Value))
+			continue
+		}
+
+		dest, err := outDest(p)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s of procedure %s: %w", p.Name, name, err)
+		}
+		args = append(args, sql.Named(p.Name, sql.Out{Dest: dest, In: p.In}))
+	}
+
+	ret := &ProcedureResult{}
+
+	var err error
+	switch engine {
+	case MySQL:
+		err = mysql.CallProcedure(ctx, ex, name, args)
+	case MSSQL:
+		var status int64
+		status, err = sqlserver.CallProcedure(ctx, ex, name, args)
+		ret.ReturnStatus = &status
+	case Oracle:
+		err = oracle.CallProcedure(ctx, ex, name, args)
+	default:
+		return nil, errors.New("stored procedures are not supported for this database engine")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ret.Out = make(map[string]any)
+	for _, arg := range args {
+		out, ok := arg.Value.(sql.Out)
+		if !ok {
+			continue
+		}
+		ret.Out[arg.Name] = derefOut(out.Dest)
+	}
+
+	return ret, nil
+}
+
+// outDest returns a pointer of the parameter's type, holding the input value for INOUT parameters.
+func outDest(p ProcedureParam) (any, error) {
+	switch p.Type {
+	case "", ParamTypeString:
+		var v string
+		if p.In && p.Value != nil {
+			v = fmt.Sprintf("%v", p.Value)
+		}
+		return &v, nil
+	case ParamTypeInt:
+		var v int64
+		if p.In && p.Value != nil {
+			if err := convert(&v, p.Value); err != nil {
+				return nil, err
+			}
+		}
+		return &v, nil
+	case ParamTypeFloat:
+		var v float64
+		if p.In && p.Value != nil {
+			if err := convert(&v, p.Value); err != nil {
+				return nil, err
+			}
+		}
+		return &v, nil
+	case ParamTypeBool:
+		var v bool
+		if p.In && p.Value != nil {
+			if err := convert(&v, p.Value); err != nil {
+				return nil, err
+			}
+		}
+		return &v, nil
+	case ParamTypeTime:
+		var v time.Time
+		if p.In && p.Value != nil {
+			t, ok := p.Value.(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("expected a time value, got %T", p.Value)
+			}
+			v = t
+		}
+		return &v, nil
+	default:
+		return nil, fmt.Errorf("unknown parameter type %q", p.Type)
+	}
+}
+
+// convert assigns src to dest using the same conversions as sql.Rows.Scan.
+func convert(dest any, src any) error {
+	switch d := dest.(type) {
+	case *int64:
+		var v sql.NullInt64
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		*d = v.Int64
+	case *float64:
+		var v sql.NullFloat64
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		*d = v.Float64
+	case *bool:
+		var v sql.NullBool
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		*d = v.Bool
+	default:
+		return fmt.Errorf("unsupported destination %T", dest)
+	}
+	return nil
+}
+
+func derefOut(dest any) any {
+	switch d := dest.(type) {
+	case *string:
+		return *d
+	case *int64:
+		return *d
+	case *float64:
+		return *d
+	case *bool:
+		return *d
+	case *time.Time:
+		return *d
+	default:
+		return dest
+	}
+}
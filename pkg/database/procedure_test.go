@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallProcedure_InvalidNames(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := CallProcedure(ctx, nil, MSSQL, "sp_grant_role; DROP TABLE users", nil)
+	require.Error(t, err)
+
+	_, err = CallProcedure(ctx, nil, MSSQL, "dbo.sp_grant_role", []ProcedureParam{{Name: "user id", In: true}})
+	require.Error(t, err)
+
+	_, err = CallProcedure(ctx, nil, SQLite, "sp_grant_role", nil)
+	require.Error(t, err)
+}
+
+func TestOutDest(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   ProcedureParam
+		want    any
+		wantErr bool
+	}{
+		{"string out", ProcedureParam{Out: true}, "", false},
+		{"string inout", ProcedureParam{In: true, Out: true, Value: 42}, "42", false},
+		{"int inout from string", ProcedureParam{In: true, Out: true, Type: ParamTypeInt, Value: "7"}, int64(7), false},
+		{"float out", ProcedureParam{Out: true, Type: ParamTypeFloat}, float64(0), false},
+		{"bool inout", ProcedureParam{In: true, Out: true, Type: ParamTypeBool, Value: "true"}, true, false},
+		{"time out", ProcedureParam{Out: true, Type: ParamTypeTime}, time.Time{}, false},
+		{"invalid int", ProcedureParam{In: true, Out: true, Type: ParamTypeInt, Value: "seven"}, nil, true},
+		{"unknown type", ProcedureParam{Out: true, Type: "uuid"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest, err := outDest(tt.param)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, derefOut(dest))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	mysqlMaxUserLength = 32
+	mysqlMaxHostLength = 255
+	maxKeywordLength   = 128
+)
+
+var keywordRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*( [A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// QuoteIdentifier validates name and quotes it as an identifier for the engine: backticks for MySQL, brackets
+// for SQL Server and double quotes for Oracle and PostgreSQL. Quote characters inside name are escaped by doubling,
+// except on Oracle, which cannot escape them, so names containing a double quote are rejected.
+func QuoteIdentifier(engine DbEngine, name string) (string, error) {
+	var open, closing string
+	var maxLength int
+	switch engine {
+	case MySQL:
+		open, closing, maxLength = "`", "`", 64
+	case MSSQL:
+		open, closing, maxLength = "[", "]", 128
+	case Oracle:
+		open, closing, maxLength = `"`, `"`, 128
+	case PostgreSQL:
+		open, closing, maxLength = `"`, `"`, 63
+	default:
+		return "", fmt.Errorf("identifier quoting is not supported for database engine %d", engine)
+	}
+
+	err := validateIdentifier(name, maxLength)
+	if err != nil {
+		return "", err
+	}
+
+	if engine == Oracle && strings.Contains(name, `"`) {
+		return "", fmt.Errorf("identifier %q contains a double quote, which Oracle does not allow in quoted identifiers", name)
+	}
+
+	return open + strings.ReplaceAll(name, closing, closing+closing) + closing, nil
+}
+
+// QuoteAccount quotes a MySQL account name of the form user@host as `user`@`host`.
+// The host is split off at the last @, and defaults to % when there is none.
+func QuoteAccount(engine DbEngine, account string) (string, error) {
+	if engine != MySQL {
+		return "", errors.New("account quoting is only supported for MySQL")
+	}
+
+	user, host := account, "%"
+	if i := strings.LastIndex(account, "@"); i >= 0 {
+		user, host = account[:i], account[i+1:]
+	}
+
+	err := validateIdentifier(user, mysqlMaxUserLength)
+	if err != nil {
+		return "", fmt.Errorf("invalid account user: %w", err)
+	}
+	err = validateIdentifier(host, mysqlMaxHostLength)
+	if err != nil {
+		return "", fmt.Errorf("invalid account host: %w", err)
+	}
+
+	return "`" + strings.ReplaceAll(user, "`", "``") + "`@`" + strings.ReplaceAll(host, "`", "``") + "`", nil
+}
+
+// ValidateKeyword checks that s is a bare SQL keyword phrase, such as the privilege CREATE SESSION, that can be
+// written into a statement unquoted. Only words of letters, digits and underscores separated by single spaces are allowed.
+func ValidateKeyword(s string) error {
+	if len(s) > maxKeywordLength {
+		return fmt.Errorf("keyword is longer than %d characters", maxKeywordLength)
+	}
+
+	if !keywordRegex.MatchString(s) {
+		return fmt.Errorf("%q is not a valid keyword", s)
+	}
+
+	return nil
+}
+
+func validateIdentifier(name string, maxLength int) error {
+	if name == "" {
+		return errors.New("identifier must not be empty")
+	}
+
+	if !utf8.ValidString(name) {
+		return errors.New("identifier must be valid UTF-8")
+	}
+
+	if utf8.RuneCountInString(name) > maxLength {
+		return fmt.Errorf("identifier is longer than %d characters", maxLength)
+	}
+
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("identifier %q contains a control character", name)
+		}
+	}
+
+	return nil
+}
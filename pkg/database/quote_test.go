@@ -0,0 +1,70 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		engine  DbEngine
+		ident   string
+		want    string
+		wantErr bool
+	}{
+		{"MySQL", MySQL, "app_role", "`app_role`", false},
+		{"MySQL escapes backticks", MySQL, "a`b", "`a``b`", false},
+		{"SQL Server", MSSQL, "db_owner", "[db_owner]", false},
+		{"SQL Server escapes brackets", MSSQL, "a]b", "[a]]b]", false},
+		{"Oracle", Oracle, "CONNECT", `"CONNECT"`, false},
+		{"Oracle rejects quotes", Oracle, `a"b`, "", true},
+		{"PostgreSQL", PostgreSQL, "pg_read_all_data", `"pg_read_all_data"`, false},
+		{"Empty", MySQL, "", "", true},
+		{"Control character", MSSQL, "a\x00b", "", true},
+		{"Too long", MySQL, strings.Repeat("a", 65), "", true},
+		{"Unsupported engine", SQLite, "a", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QuoteIdentifier(tt.engine, tt.ident)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQuoteAccount(t *testing.T) {
+	got, err := QuoteAccount(MySQL, "app@localhost")
+	require.NoError(t, err)
+	require.Equal(t, "`app`@`localhost`", got)
+
+	got, err = QuoteAccount(MySQL, "svc@corp.com@%")
+	require.NoError(t, err)
+	require.Equal(t, "`svc@corp.com`@`%`", got)
+
+	got, err = QuoteAccount(MySQL, "reporting")
+	require.NoError(t, err)
+	require.Equal(t, "`reporting`@`%`", got)
+
+	_, err = QuoteAccount(MySQL, "@localhost")
+	require.Error(t, err)
+
+	_, err = QuoteAccount(Oracle, "app@localhost")
+	require.Error(t, err)
+}
+
+func TestValidateKeyword(t *testing.T) {
+	require.NoError(t, ValidateKeyword("CREATE SESSION"))
+	require.NoError(t, ValidateKeyword("SELECT_CATALOG_ROLE"))
+	require.Error(t, ValidateKeyword(""))
+	require.Error(t, ValidateKeyword("CREATE  SESSION"))
+	require.Error(t, ValidateKeyword("DBA TO scott; DROP USER sys"))
+	require.Error(t, ValidateKeyword("CREATE\nSESSION"))
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Snapshot holds a long-lived read-only transaction so that every query run through it observes the
+// database at the same point in time. Only one query may use the snapshot at a time.
+type Snapshot struct {
+	db     *sql.DB
+	engine DbEngine
+	opts   *sql.TxOptions
+	maxAge time.Duration
+
+	mu        sync.Mutex
+	tx        *sql.Tx
+	startedAt time.Time
+	reset     bool
+}
+
+// ErrSnapshotReset is returned by Acquire after Reset, until Close starts over with a new snapshot.
+var ErrSnapshotReset = errors.New("the snapshot was discarded after a database error, the sync must be restarted")
+
+// SnapshotOptions returns the transaction options that give a consistent view for the given engine.
+// With sql.LevelDefault, MySQL uses a REPEATABLE READ read-only transaction, SQL Server uses SNAPSHOT isolation
+// (the database must have ALLOW_SNAPSHOT_ISOLATION enabled), and Oracle uses SET TRANSACTION READ ONLY.
+// Any other isolation level overrides the engine default.
+// go-mssqldb rejects read-only transactions, so SQL Server snapshots are not marked read-only. Sync only reads
+// through them either way.
+func SnapshotOptions(engine DbEngine, isolation sql.IsolationLevel) *sql.TxOptions {
+	if isolation != sql.LevelDefault {
+		// Oracle read-only transactions cannot also set an isolation level.
+		return &sql.TxOptions{Isolation: isolation, ReadOnly: engine != Oracle && engine != MSSQL}
+	}
+
+	switch engine {
+	case MSSQL:
+		return &sql.TxOptions{Isolation: sql.LevelSnapshot}
+	case Oracle:
+		return &sql.TxOptions{ReadOnly: true}
+	default:
+		return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+}
+
+// NewSnapshot returns a Snapshot for db. The transaction is started lazily on first use, and is replaced with a new one
+// once it is older than maxAge. A maxAge of zero keeps the same transaction until Close is called.
+func NewSnapshot(db *sql.DB, engine DbEngine, opts *sql.TxOptions, maxAge time.Duration) *Snapshot {
+	if opts == nil {
+		opts = SnapshotOptions(engine, sql.LevelDefault)
+	}
+
+	return &Snapshot{
+		db:     db,
+		engine: engine,
+		opts:   opts,
+		maxAge: maxAge,
+	}
+}
+
+// Acquire returns the snapshot transaction, starting a new one if required. The caller has exclusive use of the
+// transaction until it calls the returned release function, which must happen after any rows have been closed.
+func (s *Snapshot) Acquire(ctx context.Context) (*sql.Tx, func(), error) {
+	s.mu.Lock()
+
+	if s.reset {
+		s.mu.Unlock()
+		return nil, nil, ErrSnapshotReset
+	}
+
+	if s.tx != nil && s.maxAge > 0 && time.Since(s.startedAt) > s.maxAge {
+		// The snapshot is read-only, so there is nothing to lose by rolling it back.
+		_ = s.tx.Rollback()
+		s.tx = nil
+	}
+
+	if s.tx == nil {
+		// The transaction outlives the request that started it, so it must not be tied to the request's cancellation.
+		tx, err := BeginTx(context.WithoutCancel(ctx), s.db, s.engine, s.opts)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, nil, err
+		}
+		s.tx = tx
+		s.startedAt = time.Now()
+	}
+
+	return s.tx, s.mu.Unlock, nil
+}
+
+// Reset discards the current transaction after a database error, which can leave it unusable. Queries after it
+// would see a different point in time, so Acquire fails with ErrSnapshotReset until Close is called.
+func (s *Snapshot) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset = true
+	if s.tx == nil {
+		return
+	}
+	_ = s.tx.Rollback()
+	s.tx = nil
+}
+
+// Close ends the snapshot transaction. The next Acquire starts a new one.
+func (s *Snapshot) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset = false
+	if s.tx == nil {
+		return nil
+	}
+
+	err := s.tx.Rollback()
+	s.tx = nil
+	if errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+	return err
+}
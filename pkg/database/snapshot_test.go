@@ -0,0 +1,51 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		engine    DbEngine
+		isolation sql.IsolationLevel
+		want      *sql.TxOptions
+	}{
+		{"MySQL default", MySQL, sql.LevelDefault, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}},
+		{"MSSQL default", MSSQL, sql.LevelDefault, &sql.TxOptions{Isolation: sql.LevelSnapshot}},
+		{"Oracle default", Oracle, sql.LevelDefault, &sql.TxOptions{ReadOnly: true}},
+		{"MySQL override", MySQL, sql.LevelSerializable, &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}},
+		{"MSSQL override", MSSQL, sql.LevelSerializable, &sql.TxOptions{Isolation: sql.LevelSerializable}},
+		{"Oracle override", Oracle, sql.LevelSerializable, &sql.TxOptions{Isolation: sql.LevelSerializable}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SnapshotOptions(tt.engine, tt.isolation); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SnapshotOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSnapshotOptions_DriverAccepts checks that the options for every engine and every isolation level the engine
+// supports are accepted when the snapshot transaction begins.
+func TestSnapshotOptions_DriverAccepts(t *testing.T) {
+	levels := []string{"", "read_uncommitted", "read_committed", "repeatable_read", "snapshot", "serializable"}
+	for _, engine := range []DbEngine{MySQL, MSSQL, Oracle} {
+		for _, level := range levels {
+			if ValidateIsolationLevel(engine, level) != nil {
+				continue
+			}
+			isolation, err := ParseIsolationLevel(level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := SnapshotOptions(engine, isolation)
+			if err := checkTxOptions(engine, opts); err != nil {
+				t.Errorf("SnapshotOptions(%d, %q) = %+v, rejected: %v", engine, level, opts, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package sqlserver
+
+import (
+	"errors"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+const (
+	errDeadlockVictim     = 1205
+	errLockRequestTimeout = 1222
+)
+
+// IsRetryableError reports whether err is a SQL Server deadlock or lock request timeout.
+func IsRetryableError(err error) bool {
+	var mssqlErr mssql.Error
+	if !errors.As(err, &mssqlErr) {
+		return false
+	}
+
+	switch mssqlErr.Number {
+	case errDeadlockVictim, errLockRequestTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,31 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// CallProcedure calls the stored procedure name as an RPC with named arguments and returns its return status.
+// OUT parameters are passed as sql.Out values and filled in by the driver.
+func CallProcedure(ctx context.Context, ex executor, name string, args []sql.NamedArg) (int64, error) {
+	var status mssql.ReturnStatus
+
+	callArgs := make([]any, 0, len(args)+1)
+	for _, arg := range args {
+		callArgs = append(callArgs, arg)
+	}
+	callArgs = append(callArgs, &status)
+
+	_, err := ex.ExecContext(ctx, name, callArgs...)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(status), nil
+}
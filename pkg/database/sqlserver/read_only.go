@@ -0,0 +1,16 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IsReadOnly reports whether the current database only accepts reads, such as a readable secondary replica.
+func IsReadOnly(ctx context.Context, db *sql.DB) (bool, error) {
+	var updateability string
+	err := db.QueryRowContext(ctx, "SELECT CAST(DATABASEPROPERTYEX(DB_NAME(), 'Updateability') AS NVARCHAR(128))").Scan(&updateability)
+	if err != nil {
+		return false, err
+	}
+	return updateability == "READ_ONLY", nil
+}
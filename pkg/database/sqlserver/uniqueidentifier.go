@@ -0,0 +1,16 @@
+package sqlserver
+
+import (
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+// FormatUniqueIdentifier formats the bytes the driver returns for a uniqueidentifier column, which stores its
+// first three groups little-endian, as the GUID string SQL Server displays.
+func FormatUniqueIdentifier(b []byte) (string, error) {
+	var u mssql.UniqueIdentifier
+	err := u.Scan(b)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/conductorone/baton-sql/pkg/database/mysql"
+	"github.com/conductorone/baton-sql/pkg/database/oracle"
+	"github.com/conductorone/baton-sql/pkg/database/sqlserver"
+)
+
+// ParseIsolationLevel converts a configured isolation level into a sql.IsolationLevel.
+// An empty string maps to the driver default.
+func ParseIsolationLevel(level string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "", "default":
+		return sql.LevelDefault, nil
+	case "read_uncommitted":
+		return sql.LevelReadUncommitted, nil
+	case "read_committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable_read":
+		return sql.LevelRepeatableRead, nil
+	case "snapshot":
+		return sql.LevelSnapshot, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("unsupported isolation level: %s", level)
+	}
+}
+
+// ValidateIsolationLevel checks that level is a known isolation level and that the engine can start a transaction
+// with it. An Unknown engine is only checked for a known level.
+func ValidateIsolationLevel(engine DbEngine, level string) error {
+	isolation, err := ParseIsolationLevel(level)
+	if err != nil {
+		return err
+	}
+	return checkTxOptions(engine, &sql.TxOptions{Isolation: isolation})
+}
+
+// checkTxOptions reports an error if the driver of the engine rejects opts when a transaction begins.
+func checkTxOptions(engine DbEngine, opts *sql.TxOptions) error {
+	switch engine {
+	case MySQL:
+		switch opts.Isolation {
+		case sql.LevelDefault, sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable:
+		default:
+			return fmt.Errorf("mysql does not support isolation level %s", opts.Isolation)
+		}
+	case MSSQL:
+		switch opts.Isolation {
+		case sql.LevelWriteCommitted, sql.LevelLinearizable:
+			return fmt.Errorf("sql server does not support isolation level %s", opts.Isolation)
+		}
+		if opts.ReadOnly {
+			return errors.New("sql server does not support read-only transactions")
+		}
+	case Oracle:
+		switch opts.Isolation {
+		case sql.LevelDefault, sql.LevelReadCommitted, sql.LevelSerializable:
+		default:
+			return fmt.Errorf("oracle does not support isolation level %s", opts.Isolation)
+		}
+		if opts.ReadOnly && opts.Isolation != sql.LevelDefault {
+			return errors.New("oracle read-only transactions cannot set an isolation level")
+		}
+	}
+	return nil
+}
+
+// BeginTx starts a transaction on db, applying the transaction options in the way the given engine supports them.
+// Options the driver of the engine would reject fail before a connection is used.
+func BeginTx(ctx context.Context, db *sql.DB, engine DbEngine, opts *sql.TxOptions) (*sql.Tx, error) {
+	if opts != nil {
+		if err := checkTxOptions(engine, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	switch engine {
+	case Oracle:
+		return oracle.BeginTx(ctx, db, opts)
+	default:
+		return db.BeginTx(ctx, opts)
+	}
+}
+
+// IsRetryableError reports whether err is a transient error, such as a deadlock or lock wait timeout,
+// after which the failed statements can safely be retried.
+func IsRetryableError(engine DbEngine, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch engine {
+	case MySQL:
+		return mysql.IsRetryableError(err)
+	case MSSQL:
+		return sqlserver.IsRetryableError(err)
+	case Oracle:
+		return oracle.IsRetryableError(err)
+	default:
+		return false
+	}
+}
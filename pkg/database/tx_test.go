@@ -0,0 +1,99 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/sijms/go-ora/v2/network"
+)
+
+func TestParseIsolationLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		want    sql.IsolationLevel
+		wantErr bool
+	}{
+		{"Empty uses the default", "", sql.LevelDefault, false},
+		{"Read committed", "read_committed", sql.LevelReadCommitted, false},
+		{"Mixed case", "Repeatable_Read", sql.LevelRepeatableRead, false},
+		{"Snapshot", "snapshot", sql.LevelSnapshot, false},
+		{"Serializable", "serializable", sql.LevelSerializable, false},
+		{"Unknown level", "linearizable", sql.LevelDefault, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIsolationLevel(tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseIsolationLevel() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseIsolationLevel() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateIsolationLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		engine  DbEngine
+		level   string
+		wantErr bool
+	}{
+		{"MySQL repeatable read", MySQL, "repeatable_read", false},
+		{"MySQL snapshot", MySQL, "snapshot", true},
+		{"SQL Server snapshot", MSSQL, "snapshot", false},
+		{"Oracle default", Oracle, "", false},
+		{"Oracle read committed", Oracle, "read_committed", false},
+		{"Oracle serializable", Oracle, "serializable", false},
+		{"Oracle repeatable read", Oracle, "repeatable_read", true},
+		{"Oracle read uncommitted", Oracle, "read_uncommitted", true},
+		{"Oracle snapshot", Oracle, "snapshot", true},
+		{"Unknown engine", Unknown, "snapshot", false},
+		{"Unknown level", Unknown, "linearizable", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIsolationLevel(tt.engine, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIsolationLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine DbEngine
+		err    error
+		want   bool
+	}{
+		{"MySQL deadlock", MySQL, &mysqlDriver.MySQLError{Number: 1213}, true},
+		{"MySQL lock wait timeout", MySQL, &mysqlDriver.MySQLError{Number: 1205}, true},
+		{"MySQL duplicate entry", MySQL, &mysqlDriver.MySQLError{Number: 1062}, false},
+		{"Wrapped MySQL deadlock", MySQL, fmt.Errorf("grant failed: %w", &mysqlDriver.MySQLError{Number: 1213}), true},
+		{"MSSQL deadlock victim", MSSQL, mssql.Error{Number: 1205}, true},
+		{"MSSQL lock request timeout", MSSQL, mssql.Error{Number: 1222}, true},
+		{"MSSQL permission denied", MSSQL, mssql.Error{Number: 229}, false},
+		{"Oracle deadlock", Oracle, &network.OracleError{ErrCode: 60}, true},
+		{"Oracle serialization failure", Oracle, &network.OracleError{ErrCode: 8177}, true},
+		{"Oracle insufficient privileges", Oracle, &network.OracleError{ErrCode: 1031}, false},
+		{"Error from another engine", MySQL, mssql.Error{Number: 1205}, false},
+		{"Generic error", MySQL, errors.New("boom"), false},
+		{"Nil error", MySQL, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.engine, tt.err); got != tt.want {
+				t.Errorf("IsRetryableError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
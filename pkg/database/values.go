@@ -0,0 +1,368 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-sql/pkg/bcel/functions"
+	"github.com/conductorone/baton-sql/pkg/database/sqlserver"
+)
+
+// ValueConverter converts a value scanned from a column into the value that CEL expressions see.
+type ValueConverter func(v any) (any, error)
+
+// numberHint converts a column by its scale, like the decimal types of the engines.
+const numberHint = "number"
+
+// columnHints are the conversions that can be configured for a column instead of the engine's conversion.
+// numberHint is not listed, since its conversion depends on the column.
+var columnHints = map[string]ValueConverter{
+	"raw":       rawValue,
+	"string":    toString,
+	"int":       toInt,
+	"double":    toDouble,
+	"bool":      toBool,
+	"timestamp": toTimestamp,
+	"bytes":     toBytes,
+	"uuid":      toUUID,
+}
+
+// mysqlColumns converts the text the MySQL driver returns for most columns by the column's database type.
+var mysqlColumns = map[string]ValueConverter{
+	"CHAR":               toString,
+	"VARCHAR":            toString,
+	"TINYTEXT":           toString,
+	"TEXT":               toString,
+	"MEDIUMTEXT":         toString,
+	"LONGTEXT":           toString,
+	"ENUM":               toString,
+	"SET":                toString,
+	"JSON":               toString,
+	"TIME":               toString,
+	"TINYINT":            toInt,
+	"SMALLINT":           toInt,
+	"MEDIUMINT":          toInt,
+	"INT":                toInt,
+	"BIGINT":             toInt,
+	"UNSIGNED TINYINT":   toInt,
+	"UNSIGNED SMALLINT":  toInt,
+	"UNSIGNED MEDIUMINT": toInt,
+	"UNSIGNED INT":       toInt,
+	"UNSIGNED BIGINT":    toInt,
+	"YEAR":               toInt,
+	"BIT":                bitsToInt,
+	"FLOAT":              toDouble,
+	"DOUBLE":             toDouble,
+	"DATE":               toTimestamp,
+	"DATETIME":           toTimestamp,
+	"TIMESTAMP":          toTimestamp,
+}
+
+// sqlserverColumns converts the SQL Server types the driver does not already return as natural Go types.
+var sqlserverColumns = map[string]ValueConverter{
+	"REAL":             toDouble,
+	"UNIQUEIDENTIFIER": uniqueIdentifier,
+}
+
+// oracleColumns converts the binary floats the Oracle driver returns.
+var oracleColumns = map[string]ValueConverter{
+	"BFloat":  toDouble,
+	"BDouble": toDouble,
+}
+
+// numberColumns are the decimal types of each engine, which are converted by their scale.
+var numberColumns = map[DbEngine]map[string]bool{
+	MySQL:  {"DECIMAL": true},
+	MSSQL:  {"DECIMAL": true, "MONEY": true, "SMALLMONEY": true},
+	Oracle: {"NUMBER": true, "FLOAT": true},
+}
+
+// Column describes a result column as the driver reports it.
+type Column struct {
+	// DatabaseType is the type reported by sql.ColumnType.DatabaseTypeName.
+	DatabaseType string
+
+	// Scale is the number of digits after the decimal point, if ScaleOK. See sql.ColumnType.DecimalSize.
+	Scale   int64
+	ScaleOK bool
+}
+
+// ColumnConverter returns the converter for a column. Text becomes a string, whole numbers an int64, other numbers
+// a float64 and dates a time.Time. Decimals are converted by their scale rather than by each value, so every row of
+// a column has the same type: a scale of 0 gives an int64, and any other scale, or none, a float64.
+// A non-empty hint replaces the engine's conversion of the value the driver returned, so that for example a decimal
+// can keep its exact text. NULL values are always nil.
+func ColumnConverter(engine DbEngine, col Column, hint string) (ValueConverter, error) {
+	var columns map[string]ValueConverter
+	switch engine {
+	case MySQL:
+		columns = mysqlColumns
+	case MSSQL:
+		columns = sqlserverColumns
+	case Oracle:
+		columns = oracleColumns
+	}
+
+	convert, ok := columns[col.DatabaseType]
+	if !ok {
+		convert = rawValue
+	}
+	if numberColumns[engine][col.DatabaseType] {
+		convert = numberConverter(col)
+	}
+
+	switch hint {
+	case "":
+	case numberHint:
+		convert = numberConverter(col)
+	default:
+		convert, ok = columnHints[hint]
+		if !ok {
+			return nil, fmt.Errorf("unknown column type %q", hint)
+		}
+	}
+
+	return func(v any) (any, error) {
+		if v == nil {
+			return nil, nil
+		}
+		return convert(v)
+	}, nil
+}
+
+func rawValue(v any) (any, error) {
+	return v, nil
+}
+
+func toString(v any) (any, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano), nil
+	case float32:
+		return strconv.FormatFloat(float64(t), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func toInt(v any) (any, error) {
+	switch t := v.(type) {
+	case int64:
+		return t, nil
+	case int32:
+		return int64(t), nil
+	case int16:
+		return int64(t), nil
+	case int8:
+		return int64(t), nil
+	case int:
+		return int64(t), nil
+	case uint64:
+		if t > math.MaxInt64 {
+			return t, nil
+		}
+		return int64(t), nil
+	case uint32:
+		return int64(t), nil
+	case uint16:
+		return int64(t), nil
+	case uint8:
+		return int64(t), nil
+	case bool:
+		if t {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case float32:
+		return floatToInt(float64(t))
+	case float64:
+		return floatToInt(t)
+	case []byte:
+		return parseInt(string(t))
+	case string:
+		return parseInt(t)
+	default:
+		return nil, fmt.Errorf("cannot convert %T to an integer", v)
+	}
+}
+
+// parseInt parses a whole number, as a uint64 if it is too large for an int64.
+func parseInt(s string) (any, error) {
+	s = strings.TrimSpace(s)
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return i, nil
+	}
+	u, uerr := strconv.ParseUint(s, 10, 64)
+	if uerr == nil {
+		return u, nil
+	}
+	return nil, fmt.Errorf("cannot convert %q to an integer", s)
+}
+
+func floatToInt(f float64) (any, error) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return nil, fmt.Errorf("cannot convert %v to an integer", f)
+	}
+	return int64(f), nil
+}
+
+// ValidateColumnHint checks that hint is a conversion that can be configured for a column.
+func ValidateColumnHint(hint string) error {
+	if _, ok := columnHints[hint]; !ok && hint != numberHint {
+		return fmt.Errorf("unknown column type %q", hint)
+	}
+	return nil
+}
+
+// numberConverter converts a decimal column to an int64 if its scale is 0, and to a float64 otherwise. Oracle
+// reports no usable scale for a NUMBER declared without a precision, so those are float64.
+func numberConverter(col Column) ValueConverter {
+	if col.ScaleOK && col.Scale == 0 {
+		return toInt
+	}
+	return toDouble
+}
+
+func toDouble(v any) (any, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case float32:
+		// Format and parse the value so a float32 like 0.1 does not become 0.10000000149011612.
+		return strconv.ParseFloat(strconv.FormatFloat(float64(t), 'g', -1, 32), 64)
+	case int64:
+		return float64(t), nil
+	case int32:
+		return float64(t), nil
+	case int:
+		return float64(t), nil
+	case uint64:
+		return float64(t), nil
+	case []byte:
+		return parseFloat(string(t))
+	case string:
+		return parseFloat(t)
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a double", v)
+	}
+}
+
+func parseFloat(s string) (any, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %q to a double", s)
+	}
+	return f, nil
+}
+
+func toBool(v any) (any, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case []byte:
+		return parseBool(string(t))
+	case string:
+		return parseBool(t)
+	default:
+		i, err := toInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %T to a bool", v)
+		}
+		return i != int64(0), nil
+	}
+}
+
+func parseBool(s string) (any, error) {
+	b, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %q to a bool", s)
+	}
+	return b, nil
+}
+
+// toTimestamp converts a timestamp to UTC. Timestamps without a time zone are read as UTC, and MySQL's zero
+// dates are NULL.
+func toTimestamp(v any) (any, error) {
+	var s string
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC(), nil
+	case []byte:
+		s = string(t)
+	case string:
+		s = t
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a timestamp", v)
+	}
+
+	if strings.HasPrefix(s, "0000-00-00") {
+		return nil, nil
+	}
+	ts, err := functions.ParseTimestamp(s)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+func toBytes(v any) (any, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to bytes", v)
+	}
+}
+
+// toUUID formats a 16 byte UUID, such as a MySQL BINARY(16) or Oracle RAW(16) column, as a lowercase UUID string.
+func toUUID(v any) (any, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		if len(t) != 16 {
+			return string(t), nil
+		}
+		h := hex.EncodeToString(t)
+		return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a UUID", v)
+	}
+}
+
+// bitsToInt converts the big-endian bytes MySQL returns for a BIT column to an int64.
+func bitsToInt(v any) (any, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return toInt(v)
+	}
+	if len(b) > 8 {
+		return nil, fmt.Errorf("cannot convert %d bytes to an integer", len(b))
+	}
+	var ret uint64
+	for _, c := range b {
+		ret = ret<<8 | uint64(c)
+	}
+	return toInt(ret)
+}
+
+func uniqueIdentifier(v any) (any, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return v, nil
+	}
+	return sqlserver.FormatUniqueIdentifier(b)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnConverter(t *testing.T) {
+	tests := []struct {
+		name    string
+		engine  DbEngine
+		col     Column
+		hint    string
+		in      any
+		want    any
+		wantErr bool
+	}{
+		{"MySQL text", MySQL, col("VARCHAR"), "", []byte("alice"), "alice", false},
+		{"MySQL JSON", MySQL, col("JSON"), "", []byte(`{"a":1}`), `{"a":1}`, false},
+		{"MySQL text protocol int", MySQL, col("INT"), "", []byte("42"), int64(42), false},
+		{"MySQL binary protocol int", MySQL, col("INT"), "", int64(42), int64(42), false},
+		{"MySQL large unsigned bigint", MySQL, col("UNSIGNED BIGINT"), "", []byte("18446744073709551615"), uint64(18446744073709551615), false},
+		{"MySQL whole decimal", MySQL, decimal("DECIMAL", 0), "", []byte("12"), int64(12), false},
+		{"MySQL decimal", MySQL, decimal("DECIMAL", 2), "", []byte("12.50"), 12.5, false},
+		{"MySQL decimal with a whole value", MySQL, decimal("DECIMAL", 2), "", []byte("12.00"), 12.0, false},
+		{"MySQL float", MySQL, col("FLOAT"), "", float32(0.1), 0.1, false},
+		{"MySQL bit", MySQL, col("BIT"), "", []byte{0x01, 0x02}, int64(258), false},
+		{"MySQL datetime", MySQL, col("DATETIME"), "", []byte("2024-03-01 10:20:30.5"), time.Date(2024, 3, 1, 10, 20, 30, 500000000, time.UTC), false},
+		{"MySQL date", MySQL, col("DATE"), "", []byte("2024-03-01"), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"MySQL zero date", MySQL, col("DATETIME"), "", []byte("0000-00-00 00:00:00"), nil, false},
+		{"MySQL bad date", MySQL, col("DATETIME"), "", []byte("yesterday"), nil, true},
+		{"RFC 3339 timestamp", MySQL, col("VARCHAR"), "timestamp", "2024-03-01T12:20:30+02:00", time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC), false},
+		{"number hint", MySQL, decimal("INT", 0), "number", []byte("3"), int64(3), false},
+		{"MySQL blob", MySQL, col("BLOB"), "", []byte{0xff}, []byte{0xff}, false},
+		{"MySQL tinyint as bool", MySQL, col("TINYINT"), "bool", []byte("1"), true, false},
+		{"MySQL binary UUID", MySQL, col("BINARY"), "uuid",
+			[]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef},
+			"01234567-89ab-cdef-0123-456789abcdef", false},
+		{"MySQL decimal as string", MySQL, col("DECIMAL"), "string", []byte("12.50"), "12.50", false},
+		{"MySQL raw", MySQL, col("VARCHAR"), "raw", []byte("alice"), []byte("alice"), false},
+		{"MySQL bad int", MySQL, col("INT"), "", []byte("x"), nil, true},
+		{"SQL Server uniqueidentifier", MSSQL, col("UNIQUEIDENTIFIER"), "",
+			[]byte{0x67, 0x45, 0x23, 0x01, 0xab, 0x89, 0xef, 0xcd, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef},
+			"01234567-89AB-CDEF-0123-456789ABCDEF", false},
+		{"SQL Server money", MSSQL, col("MONEY"), "", []byte("10.2500"), 10.25, false},
+		{"SQL Server bit", MSSQL, col("BIT"), "", true, true, false},
+		{"SQL Server string", MSSQL, col("NVARCHAR"), "", "bob", "bob", false},
+		{"Oracle whole number", Oracle, decimal("NUMBER", 0), "", "7", int64(7), false},
+		{"Oracle number", Oracle, decimal("NUMBER", 2), "", "7.25", 7.25, false},
+		{"Oracle number without precision", Oracle, col("NUMBER"), "", "7", 7.0, false},
+		{"Oracle number without precision as int", Oracle, col("NUMBER"), "int", "7", int64(7), false},
+		{"Oracle fraction in a whole number column", Oracle, decimal("NUMBER", 0), "", "7.5", nil, true},
+		{"Oracle number as bool", Oracle, col("NUMBER"), "bool", "1", true, false},
+		{"Oracle raw UUID", Oracle, col("RAW"), "uuid", []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, "00000000-0000-0000-0000-000000000001", false},
+		{"Unknown engine", Unknown, col("TEXT"), "", []byte("a"), []byte("a"), false},
+		{"Unknown engine with hint", Unknown, col("TEXT"), "string", []byte("a"), "a", false},
+		{"NULL", MySQL, col("INT"), "bool", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convert, err := ColumnConverter(tt.engine, tt.col, tt.hint)
+			require.NoError(t, err)
+
+			got, err := convert(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestColumnConverter_unknownHint(t *testing.T) {
+	_, err := ColumnConverter(MySQL, col("INT"), "integer")
+	require.Error(t, err)
+	require.Error(t, ValidateColumnHint("integer"))
+	require.NoError(t, ValidateColumnHint("number"))
+	require.NoError(t, ValidateColumnHint("uuid"))
+}
+
+func col(dbType string) Column {
+	return Column{DatabaseType: dbType}
+}
+
+func decimal(dbType string, scale int64) Column {
+	return Column{DatabaseType: dbType, Scale: scale, ScaleOK: true}
+}